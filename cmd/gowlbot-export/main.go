@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/yannismate/gowlbot/internal/db"
+	"github.com/yannismate/gowlbot/internal/module/logging"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Exports the log archive of a guild from data.db in the working directory, e.g.
+// gowlbot-export -guild 123 -format csv -types message_delete,member_kick -after 30d
+func main() {
+	guildID := flag.String("guild", "", "guild id to export (required)")
+	formatStr := flag.String("format", string(logging.ExportFormatJSONL), "file format, jsonl or csv")
+	typesStr := flag.String("types", "", "comma separated logging types, defaults to all types")
+	afterStr := flag.String("after", "", "only events after this time, e.g. 7d, 12h or 2023-01-31")
+	beforeStr := flag.String("before", "", "only events before this time, e.g. 7d, 12h or 2023-01-31")
+	output := flag.String("out", "logs", "output file name without extension, - writes to stdout")
+	maxSize := flag.Int("max-size", 0, "split the export into files of at most this many bytes, 0 writes a single file")
+	flag.Parse()
+
+	fail := func(format string, args ...any) {
+		_, _ = fmt.Fprintf(os.Stderr, format+"\n", args...)
+		os.Exit(1)
+	}
+
+	if len(*guildID) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	format, ok := logging.ParseExportFormat(*formatStr)
+	if !ok {
+		fail("Unknown format %q.", *formatStr)
+	}
+	if *output == "-" && *maxSize > 0 {
+		fail("-max-size can't be used when writing to stdout.")
+	}
+
+	query := logging.LogRecordQuery{GuildID: *guildID}
+	for _, typeStr := range strings.Split(*typesStr, ",") {
+		typeStr = strings.TrimSpace(typeStr)
+		if len(typeStr) == 0 {
+			continue
+		}
+		logType, ok := logging.ParseLogType(typeStr)
+		if !ok {
+			fail("Unknown logging type %q.", typeStr)
+		}
+		query.LogTypes = append(query.LogTypes, logType)
+	}
+
+	now := time.Now()
+	var err error
+	if len(*afterStr) > 0 {
+		query.After, err = logging.ParseTimeFilter(*afterStr, now)
+		if err != nil {
+			fail("Invalid -after value: %v", err)
+		}
+	}
+	if len(*beforeStr) > 0 {
+		query.Before, err = logging.ParseTimeFilter(*beforeStr, now)
+		if err != nil {
+			fail("Invalid -before value: %v", err)
+		}
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fail("Could not create logger: %v", err)
+	}
+	database, err := db.ProvideDB(logger)
+	if err != nil {
+		fail("Could not open database: %v", err)
+	}
+
+	var files []*os.File
+	count, err := logging.ExportLogRecords(database, query, format, *maxSize, func() (io.Writer, error) {
+		if *output == "-" {
+			return os.Stdout, nil
+		}
+		name := *output + "." + format.FileExtension()
+		if *maxSize > 0 {
+			name = fmt.Sprintf("%s-%d.%s", *output, len(files)+1, format.FileExtension())
+		}
+		file, err := os.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		return file, nil
+	})
+	for _, file := range files {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fail("Export failed: %v", err)
+	}
+
+	if *output != "-" {
+		_, _ = fmt.Fprintf(os.Stderr, "Exported %d log records into %d file(s).\n", count, len(files))
+	}
+}
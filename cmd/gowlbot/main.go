@@ -19,6 +19,8 @@ func main() {
 	providers = append(providers, config.ProvideConfig)
 	providers = append(providers, db.ProvideDB)
 	providers = append(providers, cache.ProvideRedisClient)
+	providers = append(providers, cache.ProvideMessageStore)
+	providers = append(providers, cache.ProvideAttachmentStore)
 	providers = append(providers, discord.ProvideDiscordClient)
 	providers = append(providers, twitch.ProvideTwitch)
 	providers = append(providers, module.GetRegisteredModules()...)
@@ -0,0 +1,274 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/yannismate/gowlbot/internal/config"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAttachmentStoreDisabled = errors.New("attachment store is disabled")
+	ErrAttachmentTooLarge      = errors.New("attachment exceeds the maximum file size")
+	ErrStatusCodeFailed        = errors.New("attachment download returned a non 2xx status code")
+	ErrInvalidAttachmentLimit  = errors.New("attachment store size limits have to be positive")
+)
+
+const (
+	defaultMaxAttachmentFileSize  = 8 * 1024 * 1024
+	defaultMaxAttachmentTotalSize = 1024 * 1024 * 1024
+)
+
+// AttachmentStore keeps local copies of message attachments, so they can still be re-uploaded after the original
+// message and its CDN files have been deleted. The store is bounded by a maximum total size and evicts the oldest
+// files first. Files are encrypted like messages while the cache encryption is enabled.
+type AttachmentStore struct {
+	cfg        config.AttachmentStoreConfig
+	ttl        time.Duration
+	logger     *zap.Logger
+	httpClient *http.Client
+	encryptor  *envelopeEncryptor
+	mutex      sync.Mutex
+}
+
+func ProvideAttachmentStore(cfg *config.OwlBotConfig, logger *zap.Logger) (*AttachmentStore, error) {
+	store := AttachmentStore{
+		cfg:        cfg.Cache.AttachmentStore,
+		ttl:        cfg.Cache.MaxMessageTTL(),
+		logger:     logger,
+		httpClient: &http.Client{Timeout: time.Second * 30},
+	}
+	if !store.cfg.Enabled {
+		return &store, nil
+	}
+
+	if store.cfg.MaxFileSizeBytes == 0 {
+		store.cfg.MaxFileSizeBytes = defaultMaxAttachmentFileSize
+	}
+	if store.cfg.MaxTotalSizeBytes == 0 {
+		store.cfg.MaxTotalSizeBytes = defaultMaxAttachmentTotalSize
+	}
+	if store.cfg.MaxFileSizeBytes < 0 || store.cfg.MaxTotalSizeBytes < 0 {
+		logger.Error("Invalid attachment store size limits", zap.Int64("maxFileSize", store.cfg.MaxFileSizeBytes), zap.Int64("maxTotalSize", store.cfg.MaxTotalSizeBytes))
+		return nil, ErrInvalidAttachmentLimit
+	}
+
+	if cfg.Cache.Encryption.Enabled {
+		encryptor, err := newEnvelopeEncryptor(cfg.Cache.Encryption, logger)
+		if err != nil {
+			return nil, err
+		}
+		store.encryptor = encryptor
+	}
+
+	err := os.MkdirAll(store.cfg.Directory, 0700)
+	if err != nil {
+		logger.Error("Could not create attachment store directory", zap.String("directory", store.cfg.Directory), zap.Error(err))
+		return nil, err
+	}
+
+	go func() {
+		for range time.Tick(time.Minute) {
+			store.cleanup()
+		}
+	}()
+
+	return &store, nil
+}
+
+func (s *AttachmentStore) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// Accepts reports whether an attachment of the given size would be stored.
+func (s *AttachmentStore) Accepts(size int) bool {
+	return s.cfg.Enabled && int64(size) <= s.cfg.MaxFileSizeBytes
+}
+
+// Store downloads the attachment and keeps it for the given ttl, which can not exceed the longest message ttl.
+func (s *AttachmentStore) Store(attachmentID string, url string, ttl time.Duration) error {
+	if !s.cfg.Enabled {
+		return ErrAttachmentStoreDisabled
+	}
+
+	res, err := s.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return ErrStatusCodeFailed
+	}
+
+	var body io.Reader = io.LimitReader(res.Body, s.cfg.MaxFileSizeBytes+1)
+	if s.encryptor != nil {
+		// encrypted files are buffered, so the plaintext is never written to disk
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > s.cfg.MaxFileSizeBytes {
+			return ErrAttachmentTooLarge
+		}
+		data, err = s.encryptor.encrypt(attachmentEncryptionID(attachmentID), data)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	file, err := os.CreateTemp(s.cfg.Directory, "download-*")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+
+	written, err := io.Copy(file, body)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && s.encryptor == nil && written > s.cfg.MaxFileSizeBytes {
+		err = ErrAttachmentTooLarge
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err = os.Rename(tempPath, s.path(attachmentID))
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+	if ttl < s.ttl {
+		// expiry is based on the modification time, backdating it makes cleanup and eviction remove the file earlier
+		modTime := time.Now().Add(ttl - s.ttl)
+		err = os.Chtimes(s.path(attachmentID), modTime, modTime)
+		if err != nil {
+			s.logger.Warn("Error setting expiry of stored attachment", zap.String("attachment", attachmentID), zap.Error(err))
+		}
+	}
+	s.enforceSizeLimit()
+	return nil
+}
+
+func (s *AttachmentStore) Open(attachmentID string) (io.ReadCloser, error) {
+	if !s.cfg.Enabled {
+		return nil, ErrAttachmentStoreDisabled
+	}
+	if s.encryptor == nil {
+		return os.Open(s.path(attachmentID))
+	}
+
+	data, err := os.ReadFile(s.path(attachmentID))
+	if err != nil {
+		return nil, err
+	}
+	data, err = s.encryptor.decrypt(attachmentEncryptionID(attachmentID), data)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// attachmentEncryptionID keeps attachments and messages with the same id from being swapped.
+func attachmentEncryptionID(attachmentID string) string {
+	return "attachment:" + attachmentID
+}
+
+func (s *AttachmentStore) Delete(attachmentID string) {
+	if !s.cfg.Enabled {
+		return
+	}
+	err := os.Remove(s.path(attachmentID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Warn("Error deleting stored attachment", zap.String("attachment", attachmentID), zap.Error(err))
+	}
+}
+
+func (s *AttachmentStore) path(attachmentID string) string {
+	return filepath.Join(s.cfg.Directory, fmt.Sprintf("attachment-%s", filepath.Base(attachmentID)))
+}
+
+// cleanup removes all files that outlived the message cache, since they can not be matched to a message anymore.
+func (s *AttachmentStore) cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	files, err := s.listFiles()
+	if err != nil {
+		s.logger.Warn("Error listing attachment store directory", zap.Error(err))
+		return
+	}
+	for _, file := range files {
+		if time.Since(file.ModTime()) > s.ttl {
+			err = os.Remove(filepath.Join(s.cfg.Directory, file.Name()))
+			if err != nil {
+				s.logger.Warn("Error removing expired attachment", zap.String("file", file.Name()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// enforceSizeLimit has to be called while holding the mutex.
+func (s *AttachmentStore) enforceSizeLimit() {
+	files, err := s.listFiles()
+	if err != nil {
+		s.logger.Warn("Error listing attachment store directory", zap.Error(err))
+		return
+	}
+
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.Size()
+	}
+	if totalSize <= s.cfg.MaxTotalSizeBytes {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, file := range files {
+		if totalSize <= s.cfg.MaxTotalSizeBytes {
+			break
+		}
+		err = os.Remove(filepath.Join(s.cfg.Directory, file.Name()))
+		if err != nil {
+			s.logger.Warn("Error evicting attachment", zap.String("file", file.Name()), zap.Error(err))
+			continue
+		}
+		totalSize -= file.Size()
+	}
+}
+
+func (s *AttachmentStore) listFiles() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(s.cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
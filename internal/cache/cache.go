@@ -4,10 +4,13 @@ import (
 	"context"
 	"github.com/go-redis/redis/v9"
 	"github.com/yannismate/gowlbot/internal/config"
+	"go.uber.org/zap"
 	"time"
 )
 
-func ProvideRedisClient(cfg *config.OwlBotConfig) (*redis.Client, error) {
+// ProvideRedisClient does not fail if redis is unavailable, the client reconnects on its own and the message store
+// falls back to a local store in the meantime.
+func ProvideRedisClient(cfg *config.OwlBotConfig, logger *zap.Logger) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.Cache.URL,
 		Password: "",
@@ -19,8 +22,8 @@ func ProvideRedisClient(cfg *config.OwlBotConfig) (*redis.Client, error) {
 
 	err := client.Ping(ctx).Err()
 	if err != nil {
-		return nil, err
+		logger.Warn("Redis is unavailable", zap.String("url", cfg.Cache.URL), zap.Error(err))
 	}
 
-	return client, nil
+	return client
 }
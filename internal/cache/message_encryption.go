@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"github.com/yannismate/gowlbot/internal/config"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	encryptedMessagePrefix = "gowlenc1:"
+	dataKeySize            = 32
+)
+
+var (
+	ErrUnknownEncryptionKey = errors.New("message was encrypted with an unknown key")
+	ErrInvalidEncryptedData = errors.New("encrypted message is malformed")
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key configuration")
+)
+
+// EncryptedMessageStore encrypts messages before passing them to the underlying store.
+type EncryptedMessageStore struct {
+	store     MessageStore
+	encryptor *envelopeEncryptor
+}
+
+// envelopeEncryptor gives every message or file its own data key, which is stored next to it encrypted with the
+// configured master key:
+//
+//	gowlenc1:<key id>:<wrapped key length><wrapped key><nonce><ciphertext>
+//
+// Data without the prefix was stored before encryption was enabled and is returned unchanged.
+type envelopeEncryptor struct {
+	currentKeyID string
+	masterKeys   map[string]cipher.AEAD
+}
+
+func newEncryptedMessageStore(store MessageStore, cfg config.EncryptionConfig, logger *zap.Logger) (*EncryptedMessageStore, error) {
+	encryptor, err := newEnvelopeEncryptor(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedMessageStore{store: store, encryptor: encryptor}, nil
+}
+
+func newEnvelopeEncryptor(cfg config.EncryptionConfig, logger *zap.Logger) (*envelopeEncryptor, error) {
+	encodedKeys := make(map[string]string)
+	for keyID, key := range cfg.Keys {
+		encodedKeys[keyID] = key
+	}
+	if len(cfg.KeyFile) > 0 {
+		file, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			logger.Error("Could not read encryption key file", zap.String("file", cfg.KeyFile), zap.Error(err))
+			return nil, err
+		}
+		fileKeys := make(map[string]string)
+		err = yaml.Unmarshal(file, &fileKeys)
+		if err != nil {
+			logger.Error("Could not parse encryption key file", zap.String("file", cfg.KeyFile), zap.Error(err))
+			return nil, err
+		}
+		for keyID, key := range fileKeys {
+			encodedKeys[keyID] = key
+		}
+	}
+
+	masterKeys := make(map[string]cipher.AEAD)
+	for keyID, encodedKey := range encodedKeys {
+		if len(keyID) == 0 || strings.Contains(keyID, ":") {
+			logger.Error("Encryption key ids must not be empty or contain colons", zap.String("keyID", keyID))
+			return nil, ErrInvalidEncryptionKey
+		}
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			logger.Error("Encryption key is not valid base64", zap.String("keyID", keyID), zap.Error(err))
+			return nil, ErrInvalidEncryptionKey
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			logger.Error("Invalid encryption key", zap.String("keyID", keyID), zap.Error(err))
+			return nil, ErrInvalidEncryptionKey
+		}
+		masterKeys[keyID] = aead
+	}
+
+	if _, ok := masterKeys[cfg.KeyID]; !ok {
+		logger.Error("The current encryption key id has no key", zap.String("keyID", cfg.KeyID))
+		return nil, ErrInvalidEncryptionKey
+	}
+
+	return &envelopeEncryptor{
+		currentKeyID: cfg.KeyID,
+		masterKeys:   masterKeys,
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *EncryptedMessageStore) Get(ctx context.Context, messageID string) ([]byte, error) {
+	data, err := s.store.Get(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	return s.encryptor.decrypt(messageID, data)
+}
+
+func (s *EncryptedMessageStore) Set(ctx context.Context, messageID string, data []byte, ttl time.Duration) error {
+	encrypted, err := s.encryptor.encrypt(messageID, data)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, messageID, encrypted, ttl)
+}
+
+// encrypt binds the ciphertext to the id, so entries can't be swapped between messages or files.
+func (s *envelopeEncryptor) encrypt(id string, data []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := seal(s.masterKeys[s.currentKeyID], dataKey, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(dataAEAD, data, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(encryptedMessagePrefix + s.currentKeyID + ":")
+	_ = binary.Write(&buf, binary.BigEndian, uint16(len(wrappedKey)))
+	buf.Write(wrappedKey)
+	buf.Write(ciphertext)
+	return buf.Bytes(), nil
+}
+
+func (s *envelopeEncryptor) decrypt(id string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedMessagePrefix)) {
+		return data, nil
+	}
+
+	keyID, payload, ok := bytes.Cut(data[len(encryptedMessagePrefix):], []byte(":"))
+	if !ok || len(payload) < 2 {
+		return nil, ErrInvalidEncryptedData
+	}
+	masterKey, ok := s.masterKeys[string(keyID)]
+	if !ok {
+		return nil, ErrUnknownEncryptionKey
+	}
+
+	wrappedKeyLength := int(binary.BigEndian.Uint16(payload))
+	payload = payload[2:]
+	if len(payload) < wrappedKeyLength {
+		return nil, ErrInvalidEncryptedData
+	}
+
+	dataKey, err := open(masterKey, payload[:wrappedKeyLength], []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(dataAEAD, payload[wrappedKeyLength:], []byte(id))
+}
+
+// seal returns the random nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidEncryptedData
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/yannismate/gowlbot/internal/config"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func newTestEncryptedStore(t *testing.T, store MessageStore, keyID string, keys map[string]string) *EncryptedMessageStore {
+	t.Helper()
+	encryptedStore, err := newEncryptedMessageStore(store, config.EncryptionConfig{Enabled: true, KeyID: keyID, Keys: keys}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("creating encrypted store: %v", err)
+	}
+	return encryptedStore
+}
+
+func testEncryptionKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, dataKeySize))
+}
+
+func TestEncryptedMessageStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryMessageStore(10)
+	encryptedStore := newTestEncryptedStore(t, store, "k1", map[string]string{"k1": testEncryptionKey(1)})
+
+	message := []byte(`{"Content":"hello world"}`)
+	if err := encryptedStore.Set(ctx, "1", message, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	stored, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("reading underlying store: %v", err)
+	}
+	if !bytes.HasPrefix(stored, []byte(encryptedMessagePrefix+"k1:")) || bytes.Contains(stored, []byte("hello world")) {
+		t.Fatalf("message was not encrypted: %q", stored)
+	}
+
+	decrypted, err := encryptedStore.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(decrypted, message) {
+		t.Fatalf("got %q, want %q", decrypted, message)
+	}
+}
+
+func TestEncryptedMessageStoreKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryMessageStore(10)
+	oldStore := newTestEncryptedStore(t, store, "old", map[string]string{"old": testEncryptionKey(1)})
+
+	message := []byte(`{"Content":"before rotation"}`)
+	if err := oldStore.Set(ctx, "1", message, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	rotatedStore := newTestEncryptedStore(t, store, "new", map[string]string{"old": testEncryptionKey(1), "new": testEncryptionKey(2)})
+	decrypted, err := rotatedStore.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get with rotated keys: %v", err)
+	}
+	if !bytes.Equal(decrypted, message) {
+		t.Fatalf("got %q, want %q", decrypted, message)
+	}
+
+	if err = rotatedStore.Set(ctx, "2", message, time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	stored, err := store.Get(ctx, "2")
+	if err != nil {
+		t.Fatalf("reading underlying store: %v", err)
+	}
+	if !bytes.HasPrefix(stored, []byte(encryptedMessagePrefix+"new:")) {
+		t.Fatalf("new messages are not encrypted with the current key: %q", stored)
+	}
+
+	if _, err = oldStore.Get(ctx, "2"); !errors.Is(err, ErrUnknownEncryptionKey) {
+		t.Fatalf("got %v, want ErrUnknownEncryptionKey", err)
+	}
+}
+
+func TestEncryptedMessageStoreSwappedMessageID(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryMessageStore(10)
+	encryptedStore := newTestEncryptedStore(t, store, "k1", map[string]string{"k1": testEncryptionKey(1)})
+
+	if err := encryptedStore.Set(ctx, "1", []byte("secret"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	stored, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("reading underlying store: %v", err)
+	}
+	if err = store.Set(ctx, "2", stored, time.Minute); err != nil {
+		t.Fatalf("writing underlying store: %v", err)
+	}
+
+	if data, err := encryptedStore.Get(ctx, "2"); err == nil {
+		t.Fatalf("swapped message was decrypted: %q", data)
+	}
+}
+
+func TestEncryptedMessageStorePlaintextPassthrough(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryMessageStore(10)
+	encryptedStore := newTestEncryptedStore(t, store, "k1", map[string]string{"k1": testEncryptionKey(1)})
+
+	message := []byte(`{"Content":"stored before encryption was enabled"}`)
+	if err := store.Set(ctx, "1", message, time.Minute); err != nil {
+		t.Fatalf("writing underlying store: %v", err)
+	}
+
+	data, err := encryptedStore.Get(ctx, "1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(data, message) {
+		t.Fatalf("got %q, want %q", data, message)
+	}
+}
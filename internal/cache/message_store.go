@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v9"
+	"github.com/yannismate/gowlbot/internal/config"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+const (
+	MessageStoreRedis  = "redis"
+	MessageStoreSQLite = "sqlite"
+	MessageStoreMemory = "memory"
+	MessageStoreNone   = "none"
+
+	defaultMemoryMaxMessages = 100_000
+	primaryStoreTimeout      = time.Millisecond * 500
+	recoveryCheckInterval    = time.Second * 10
+)
+
+var (
+	ErrMessageNotFound         = errors.New("message not found in store")
+	ErrUnknownMessageStoreType = errors.New("unknown message store type")
+)
+
+// MessageStore keeps serialized messages for a limited time, so their previous content is still known when they are
+// edited or deleted.
+type MessageStore interface {
+	// Get returns ErrMessageNotFound if the message was never stored or has expired.
+	Get(ctx context.Context, messageID string) ([]byte, error)
+	Set(ctx context.Context, messageID string, data []byte, ttl time.Duration) error
+}
+
+func ProvideMessageStore(cfg *config.OwlBotConfig, redisClient *redis.Client, db *gorm.DB, logger *zap.Logger) (MessageStore, error) {
+	store, err := newMessageStore(cfg.Cache.MessageStore, redisClient, db, logger)
+	if err != nil || !cfg.Cache.Encryption.Enabled {
+		return store, err
+	}
+	return newEncryptedMessageStore(store, cfg.Cache.Encryption, logger)
+}
+
+func newMessageStore(storeCfg config.MessageStoreConfig, redisClient *redis.Client, db *gorm.DB, logger *zap.Logger) (MessageStore, error) {
+	storeType := storeCfg.Type
+	if len(storeType) == 0 {
+		storeType = MessageStoreRedis
+	}
+	if storeType != MessageStoreRedis {
+		return newLocalMessageStore(storeType, storeCfg, db, logger)
+	}
+
+	fallbackType := storeCfg.Fallback
+	if len(fallbackType) == 0 {
+		fallbackType = MessageStoreMemory
+	}
+	primary := newRedisMessageStore(redisClient)
+	if fallbackType == MessageStoreNone {
+		return primary, nil
+	}
+
+	fallback, err := newLocalMessageStore(fallbackType, storeCfg, db, logger)
+	if err != nil {
+		return nil, err
+	}
+	store := &FallbackMessageStore{
+		primary:     primary,
+		fallback:    fallback,
+		logger:      logger,
+		fallbackIDs: make(map[string]time.Time),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = primary.Ping(ctx)
+	if err != nil {
+		store.degrade(err)
+	}
+
+	return store, nil
+}
+
+func newLocalMessageStore(storeType string, storeCfg config.MessageStoreConfig, db *gorm.DB, logger *zap.Logger) (MessageStore, error) {
+	switch storeType {
+	case MessageStoreSQLite:
+		return newSQLiteMessageStore(db, logger)
+	case MessageStoreMemory:
+		maxMessages := storeCfg.MemoryMaxMessages
+		if maxMessages <= 0 {
+			maxMessages = defaultMemoryMaxMessages
+		}
+		return newMemoryMessageStore(maxMessages), nil
+	}
+	logger.Error("Unknown message store type", zap.String("type", storeType))
+	return nil, ErrUnknownMessageStoreType
+}
+
+// FallbackMessageStore uses redis while it is reachable and a local store while it is not. Redis is checked
+// periodically during an outage and used again as soon as it answers. Only messages are covered, invite tracking and
+// paginated log searches are unavailable while redis is down.
+type FallbackMessageStore struct {
+	primary  *RedisMessageStore
+	fallback MessageStore
+	logger   *zap.Logger
+	mutex    sync.RWMutex
+	degraded bool
+	// fallbackIDs holds the expiry of messages written to the fallback store, redis may still have an older version
+	// of them from before the outage
+	fallbackIDs map[string]time.Time
+	lastPrune   time.Time
+}
+
+func (s *FallbackMessageStore) Get(ctx context.Context, messageID string) ([]byte, error) {
+	if s.isInFallback(messageID) {
+		data, err := s.fallback.Get(ctx, messageID)
+		if !errors.Is(err, ErrMessageNotFound) {
+			return data, err
+		}
+	}
+	if !s.isDegraded() {
+		primaryCtx, cancel := context.WithTimeout(ctx, primaryStoreTimeout)
+		data, err := s.primary.Get(primaryCtx, messageID)
+		cancel()
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, ErrMessageNotFound) {
+			s.degrade(err)
+		}
+	}
+	// messages received during an outage are only known to the fallback store
+	return s.fallback.Get(ctx, messageID)
+}
+
+func (s *FallbackMessageStore) Set(ctx context.Context, messageID string, data []byte, ttl time.Duration) error {
+	if !s.isDegraded() {
+		primaryCtx, cancel := context.WithTimeout(ctx, primaryStoreTimeout)
+		err := s.primary.Set(primaryCtx, messageID, data, ttl)
+		cancel()
+		if err == nil {
+			s.forgetFallbackID(messageID)
+			return nil
+		}
+		s.degrade(err)
+	}
+	err := s.fallback.Set(ctx, messageID, data, ttl)
+	if err == nil {
+		s.mutex.Lock()
+		s.fallbackIDs[messageID] = time.Now().Add(ttl)
+		s.mutex.Unlock()
+	}
+	return err
+}
+
+func (s *FallbackMessageStore) isInFallback(messageID string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	expiry, ok := s.fallbackIDs[messageID]
+	return ok && time.Now().Before(expiry)
+}
+
+// forgetFallbackID is called once redis has the latest version of the message again, expired ids are pruned along
+// the way.
+func (s *FallbackMessageStore) forgetFallbackID(messageID string) {
+	s.mutex.RLock()
+	empty := len(s.fallbackIDs) == 0
+	s.mutex.RUnlock()
+	if empty {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.fallbackIDs, messageID)
+
+	now := time.Now()
+	if now.Sub(s.lastPrune) < recoveryCheckInterval {
+		return
+	}
+	s.lastPrune = now
+	for id, expiry := range s.fallbackIDs {
+		if now.After(expiry) {
+			delete(s.fallbackIDs, id)
+		}
+	}
+}
+
+func (s *FallbackMessageStore) isDegraded() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.degraded
+}
+
+func (s *FallbackMessageStore) degrade(cause error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.degraded {
+		return
+	}
+	s.degraded = true
+	s.logger.Warn("Redis is unavailable, using the fallback message store", zap.Error(cause))
+	go s.waitForRecovery()
+}
+
+func (s *FallbackMessageStore) waitForRecovery() {
+	ticker := time.NewTicker(recoveryCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := s.primary.Ping(ctx)
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		s.mutex.Lock()
+		s.degraded = false
+		s.mutex.Unlock()
+		s.logger.Info("Redis is available again, using it as message store")
+		return
+	}
+}
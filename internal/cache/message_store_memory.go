@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryMessage struct {
+	messageID string
+	data      []byte
+	expiresAt time.Time
+}
+
+// MemoryMessageStore keeps up to maxMessages messages in memory and evicts the least recently used first.
+type MemoryMessageStore struct {
+	maxMessages int
+	mutex       sync.Mutex
+	order       *list.List
+	messages    map[string]*list.Element
+}
+
+func newMemoryMessageStore(maxMessages int) *MemoryMessageStore {
+	return &MemoryMessageStore{
+		maxMessages: maxMessages,
+		order:       list.New(),
+		messages:    make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryMessageStore) Get(_ context.Context, messageID string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.messages[messageID]
+	if !ok {
+		return nil, ErrMessageNotFound
+	}
+	message := element.Value.(*memoryMessage)
+	if time.Now().After(message.expiresAt) {
+		s.order.Remove(element)
+		delete(s.messages, messageID)
+		return nil, ErrMessageNotFound
+	}
+	s.order.MoveToFront(element)
+	return message.data, nil
+}
+
+func (s *MemoryMessageStore) Set(_ context.Context, messageID string, data []byte, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	message := &memoryMessage{messageID: messageID, data: data, expiresAt: time.Now().Add(ttl)}
+	if element, ok := s.messages[messageID]; ok {
+		element.Value = message
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.messages[messageID] = s.order.PushFront(message)
+	for s.order.Len() > s.maxMessages {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.messages, oldest.Value.(*memoryMessage).messageID)
+	}
+	return nil
+}
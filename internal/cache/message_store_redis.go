@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v9"
+	"time"
+)
+
+type RedisMessageStore struct {
+	client *redis.Client
+}
+
+func newRedisMessageStore(client *redis.Client) *RedisMessageStore {
+	return &RedisMessageStore{client: client}
+}
+
+func messageKey(messageID string) string {
+	return "discord-msg:" + messageID
+}
+
+func (s *RedisMessageStore) Get(ctx context.Context, messageID string) ([]byte, error) {
+	data, err := s.client.Get(ctx, messageKey(messageID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMessageNotFound
+	}
+	return data, err
+}
+
+func (s *RedisMessageStore) Set(ctx context.Context, messageID string, data []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, messageKey(messageID), data, ttl).Err()
+}
+
+func (s *RedisMessageStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
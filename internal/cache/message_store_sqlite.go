@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type StoredMessage struct {
+	MessageID string `gorm:"primaryKey"`
+	Data      []byte
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// SQLiteMessageStore keeps messages in the bot database, so they survive restarts without redis.
+type SQLiteMessageStore struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func newSQLiteMessageStore(db *gorm.DB, logger *zap.Logger) (*SQLiteMessageStore, error) {
+	err := db.AutoMigrate(&StoredMessage{})
+	if err != nil {
+		logger.Error("Could not prepare database for message store", zap.Error(err))
+		return nil, err
+	}
+
+	store := SQLiteMessageStore{db: db, logger: logger}
+	go func() {
+		for range time.Tick(time.Minute) {
+			store.cleanup()
+		}
+	}()
+
+	return &store, nil
+}
+
+func (s *SQLiteMessageStore) Get(ctx context.Context, messageID string) ([]byte, error) {
+	var message StoredMessage
+	result := s.db.WithContext(ctx).Where("message_id = ? AND expires_at > ?", messageID, time.Now()).Limit(1).Find(&message)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrMessageNotFound
+	}
+	return message.Data, nil
+}
+
+func (s *SQLiteMessageStore) Set(ctx context.Context, messageID string, data []byte, ttl time.Duration) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&StoredMessage{
+		MessageID: messageID,
+		Data:      data,
+		ExpiresAt: time.Now().Add(ttl),
+	}).Error
+}
+
+func (s *SQLiteMessageStore) cleanup() {
+	result := s.db.Where("expires_at <= ?", time.Now()).Delete(&StoredMessage{})
+	if result.Error != nil {
+		s.logger.Error("Error deleting expired messages from message store", zap.Error(result.Error))
+	}
+}
@@ -1,9 +1,12 @@
 package config
 
+import "time"
+
 type OwlBotConfig struct {
 	Discord DiscordConfig `yaml:"discord"`
 	Cache   CacheConfig   `yaml:"cache"`
 	Twitch  TwitchConfig  `yaml:"twitch"`
+	Logging LoggingConfig `yaml:"logging"`
 }
 
 type DiscordConfig struct {
@@ -14,6 +17,58 @@ type DiscordConfig struct {
 type CacheConfig struct {
 	URL               string `yaml:"url"`
 	MessageTTLMinutes int    `yaml:"message-ttl-minutes"`
+	// MaxMessageTTLMinutes bounds the message ttl guilds can configure, defaults to MessageTTLMinutes
+	MaxMessageTTLMinutes int                   `yaml:"max-message-ttl-minutes"`
+	MessageStore         MessageStoreConfig    `yaml:"message-store"`
+	Encryption           EncryptionConfig      `yaml:"encryption"`
+	AttachmentStore      AttachmentStoreConfig `yaml:"attachment-store"`
+}
+
+// MaxMessageTTL returns the longest time a message can be cached for any guild.
+func (c CacheConfig) MaxMessageTTL() time.Duration {
+	if c.MaxMessageTTLMinutes > c.MessageTTLMinutes {
+		return time.Minute * time.Duration(c.MaxMessageTTLMinutes)
+	}
+	return time.Minute * time.Duration(c.MessageTTLMinutes)
+}
+
+type EncryptionConfig struct {
+	// Enabled encrypts cached messages and the files of the attachment store
+	Enabled bool `yaml:"enabled"`
+	// KeyID selects the key new messages are encrypted with, older keys are kept for decryption during a rotation
+	KeyID string `yaml:"key-id"`
+	// Keys maps key ids to base64 encoded 16, 24 or 32 byte AES keys
+	Keys map[string]string `yaml:"keys"`
+	// KeyFile is a yaml file with the same format as Keys, so keys don't have to be stored in the config
+	KeyFile string `yaml:"key-file"`
+}
+
+type MessageStoreConfig struct {
+	// Type is one of redis, sqlite or memory, defaults to redis
+	Type string `yaml:"type"`
+	// Fallback is used while redis is unavailable, one of sqlite, memory or none, defaults to memory. It only stores
+	// messages, invite tracking and paginated log searches still need redis
+	Fallback string `yaml:"fallback"`
+	// MemoryMaxMessages bounds the memory store, defaults to 100000
+	MemoryMaxMessages int `yaml:"memory-max-messages"`
+}
+
+type AttachmentStoreConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Directory string `yaml:"directory"`
+	// MaxFileSizeBytes defaults to 8 MiB
+	MaxFileSizeBytes int64 `yaml:"max-file-size-bytes"`
+	// MaxTotalSizeBytes defaults to 1 GiB, the oldest files are evicted beyond it
+	MaxTotalSizeBytes int64 `yaml:"max-total-size-bytes"`
+}
+
+type LoggingConfig struct {
+	// ArchiveRetentionDays is used for guilds that did not configure their own retention, defaults to 0
+	// which disables the archive
+	ArchiveRetentionDays int `yaml:"archive-retention-days"`
+	// CacheWarmupMessages is the number of messages per channel cached on startup for guilds that did not configure
+	// their own, 0 disables the warm-up
+	CacheWarmupMessages int `yaml:"cache-warmup-messages"`
 }
 
 type TwitchConfig struct {
@@ -13,6 +13,14 @@ type VersionedSlashCommand struct {
 	Version string
 }
 
+// Type returns whether the command is a slash command or a user or message context menu command.
+func (c VersionedSlashCommand) Type() discordgo.ApplicationCommandType {
+	if c.Command.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return c.Command.Type
+}
+
 func ProvideDiscordClient(cfg *config.OwlBotConfig, logger *zap.Logger) (*discordgo.Session, error) {
 	session, err := discordgo.New("Bot " + cfg.Discord.BotToken)
 	session.Identify.Intents = discordgo.IntentsAll
@@ -22,12 +30,15 @@ func ProvideDiscordClient(cfg *config.OwlBotConfig, logger *zap.Logger) (*discor
 	// necessary for Role Change Logging
 	session.State.TrackMembers = true
 	session.State.TrackEmojis = false
-	session.State.TrackChannels = false
+	// necessary for Channel Logging
+	session.State.TrackChannels = true
 	session.State.TrackPresences = false
-	session.State.TrackRoles = false
+	// necessary for Role Logging
+	session.State.TrackRoles = true
 	session.State.TrackThreadMembers = false
-	session.State.TrackThreads = false
-	session.State.TrackVoice = false
+	session.State.TrackThreads = true
+	// necessary for Voice Logging
+	session.State.TrackVoice = true
 
 	if err != nil {
 		logger.Error("Error creating discord client", zap.Error(err))
@@ -0,0 +1,247 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/yannismate/gowlbot/internal/templating"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxArchiveRetentionDays = 365
+	archiveCleanupInterval  = time.Hour
+	archiveSummaryLength    = 200
+)
+
+// archivedContentKeys hold what the author wrote, which is only archived where the guild allows caching it.
+var archivedContentKeys = []string{"previous_content", "new_content", "content_diff", "content_diff_plain", "attachments", "removed_attachments"}
+
+var relativeTimeRegex = regexp.MustCompile(`^(\d+)\s*([mhdw])$`)
+
+func (m *Module) startArchiveCleanup() {
+	go func() {
+		m.cleanupLogArchive()
+		for range time.Tick(archiveCleanupInterval) {
+			m.cleanupLogArchive()
+		}
+	}()
+}
+
+// defaultArchiveRetentionDays returns the retention of guilds that did not configure their own, the archive is
+// disabled unless the bot config enables it.
+func (m *Module) defaultArchiveRetentionDays() int {
+	if m.config.Logging.ArchiveRetentionDays > 0 {
+		return m.config.Logging.ArchiveRetentionDays
+	}
+	return 0
+}
+
+// getArchiveRetentionDays returns how long log records of the guild are kept, 0 disables the archive.
+func (m *Module) getArchiveRetentionDays(guildID string) int {
+	guildConfig := m.getGuildLoggingConfig(guildID)
+	if guildConfig.ArchiveRetentionDays == nil {
+		return m.defaultArchiveRetentionDays()
+	}
+	return *guildConfig.ArchiveRetentionDays
+}
+
+func (m *Module) archiveLogEvent(guildID string, logType LogType, data map[string]string) {
+	if m.getArchiveRetentionDays(guildID) == 0 {
+		return
+	}
+
+	if !m.cachesContentOf(m.getMessageCachePolicy(guildID), data["channel_id"]) {
+		archivedData := make(map[string]string, len(data))
+		for key, value := range data {
+			archivedData[key] = value
+		}
+		for _, key := range archivedContentKeys {
+			if _, ok := archivedData[key]; ok {
+				archivedData[key] = contentNotCached
+			}
+		}
+		data = archivedData
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		m.logger.Error("Error serializing log record payload", zap.String("guild", guildID), zap.Error(err))
+		return
+	}
+
+	actorID, targetID := getLogRecordActorAndTarget(data)
+	record := LogRecord{
+		GuildID:   guildID,
+		LogType:   logType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		ChannelID: data["channel_id"],
+		Payload:   string(payload),
+	}
+	result := m.db.Create(&record)
+	if result.Error != nil {
+		m.logger.Error("Error storing log record in db", zap.String("guild", guildID), zap.Any("logType", logType), zap.Error(result.Error))
+	}
+}
+
+// getLogRecordActorAndTarget returns who caused the event and who it affected, which is the same user for events
+// like message edits.
+func getLogRecordActorAndTarget(data map[string]string) (string, string) {
+	firstNonEmpty := func(keys ...string) string {
+		for _, key := range keys {
+			if value := data[key]; len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+	return firstNonEmpty("moderator_id", "author_id", "inviter_id", "member_id"), firstNonEmpty("member_id", "author_id")
+}
+
+func (m *Module) cleanupLogArchive() {
+	var guildConfigs []GuildLoggingConfig
+	result := m.db.Where("archive_retention_days IS NOT NULL").Find(&guildConfigs)
+	if result.Error != nil {
+		m.logger.Error("Error fetching guild logging configs from db", zap.Error(result.Error))
+		return
+	}
+
+	now := time.Now()
+	var configuredGuildIDs []string
+	var deleted int64
+	for _, guildConfig := range guildConfigs {
+		configuredGuildIDs = append(configuredGuildIDs, guildConfig.GuildID)
+		cutoff := now.AddDate(0, 0, -*guildConfig.ArchiveRetentionDays)
+		result = m.db.Where("guild_id = ? AND created_at < ?", guildConfig.GuildID, cutoff).Delete(&LogRecord{})
+		if result.Error != nil {
+			m.logger.Error("Error deleting expired log records", zap.String("guild", guildConfig.GuildID), zap.Error(result.Error))
+			continue
+		}
+		deleted += result.RowsAffected
+	}
+
+	query := m.db.Where("created_at < ?", now.AddDate(0, 0, -m.defaultArchiveRetentionDays()))
+	if len(configuredGuildIDs) > 0 {
+		query = query.Where("guild_id NOT IN ?", configuredGuildIDs)
+	}
+	result = query.Delete(&LogRecord{})
+	if result.Error != nil {
+		m.logger.Error("Error deleting expired log records", zap.Error(result.Error))
+		return
+	}
+	deleted += result.RowsAffected
+
+	if deleted > 0 {
+		m.logger.Info("Deleted expired log records", zap.Int64("count", deleted))
+	}
+}
+
+// LogRecordQuery filters archived log records, empty fields match everything. Searches keep it in the cache while
+// they are paginated, since button custom ids are too short for it.
+type LogRecordQuery struct {
+	GuildID   string
+	UserID    string
+	ChannelID string
+	LogTypes  []LogType
+	After     time.Time
+	Before    time.Time
+}
+
+func (q *LogRecordQuery) MarshalBinary() ([]byte, error) {
+	return json.Marshal(q)
+}
+
+func (q *LogRecordQuery) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, q)
+}
+
+func (m *Module) searchLogRecords(query LogRecordQuery, offset int, limit int) ([]LogRecord, int64, error) {
+	// the session allows reusing the conditions for both the count and the page query
+	dbQuery := buildLogRecordQuery(m.db, query).Session(&gorm.Session{})
+
+	var total int64
+	err := dbQuery.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []LogRecord
+	err = dbQuery.Order("created_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error
+	return records, total, err
+}
+
+func buildLogRecordQuery(db *gorm.DB, query LogRecordQuery) *gorm.DB {
+	dbQuery := db.Model(&LogRecord{}).Where("guild_id = ?", query.GuildID)
+	if len(query.UserID) > 0 {
+		dbQuery = dbQuery.Where("(actor_id = ? OR target_id = ?)", query.UserID, query.UserID)
+	}
+	if len(query.ChannelID) > 0 {
+		dbQuery = dbQuery.Where("channel_id = ?", query.ChannelID)
+	}
+	if len(query.LogTypes) > 0 {
+		dbQuery = dbQuery.Where("log_type IN ?", query.LogTypes)
+	}
+	if !query.After.IsZero() {
+		dbQuery = dbQuery.Where("created_at >= ?", query.After)
+	}
+	if !query.Before.IsZero() {
+		dbQuery = dbQuery.Where("created_at <= ?", query.Before)
+	}
+	return dbQuery
+}
+
+// formatLogRecordSummary renders the record with the default format of its type into a single line.
+func formatLogRecordSummary(record LogRecord) string {
+	data := make(map[string]string)
+	err := json.Unmarshal([]byte(record.Payload), &data)
+	if err != nil {
+		return record.LogType.ToReadableString()
+	}
+
+	tmpl, err := templating.Parse(defaultLoggingFormats[record.LogType])
+	if err != nil {
+		return record.LogType.ToReadableString()
+	}
+	// ansi colors can only be displayed in code blocks
+	if plainDiff, ok := data["content_diff_plain"]; ok {
+		data["content_diff"] = plainDiff
+	}
+
+	summary := strings.Join(strings.Fields(tmpl.Execute(data)), " ")
+	if utf8.RuneCountInString(summary) > archiveSummaryLength {
+		summary = substringUTF8(summary, 0, archiveSummaryLength-1) + "…"
+	}
+	return summary
+}
+
+// ParseTimeFilter accepts relative times like "3w" or "12h" ago, dates like "2023-01-31" and RFC 3339 timestamps.
+func ParseTimeFilter(input string, now time.Time) (time.Time, error) {
+	input = strings.TrimSpace(input)
+
+	if match := relativeTimeRegex.FindStringSubmatch(input); match != nil {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		units := map[string]time.Duration{
+			"m": time.Minute,
+			"h": time.Hour,
+			"d": time.Hour * 24,
+			"w": time.Hour * 24 * 7,
+		}
+		return now.Add(-time.Duration(amount) * units[match[2]]), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if parsed, err := time.Parse(layout, input); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, errors.New("use a relative time like `3w`, `7d` or `12h`, or a date like `2023-01-31`")
+}
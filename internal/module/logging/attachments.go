@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+	"time"
+)
+
+// Discords upload limit for bots, shared by all files of a message
+const discordUploadLimitBytes = 8 * 1024 * 1024
+
+func cacheAttachments(attachments []*discordgo.MessageAttachment) []CachedAttachment {
+	var cached []CachedAttachment
+	for _, attachment := range attachments {
+		cached = append(cached, CachedAttachment{
+			ID:          attachment.ID,
+			Filename:    attachment.Filename,
+			Size:        attachment.Size,
+			ContentType: attachment.ContentType,
+			URL:         attachment.URL,
+		})
+	}
+	return cached
+}
+
+func (m *Module) storeMessageAttachments(msg *discordgo.Message, ttl time.Duration) {
+	if !m.attachments.Enabled() {
+		return
+	}
+	for _, attachment := range msg.Attachments {
+		if !m.attachments.Accepts(attachment.Size) {
+			continue
+		}
+		err := m.attachments.Store(attachment.ID, attachment.URL, ttl)
+		if err != nil {
+			m.logger.Warn("Error storing message attachment", zap.String("message", msg.ID), zap.String("attachment", attachment.ID), zap.Error(err))
+		}
+	}
+}
+
+// openStoredAttachments opens all locally stored copies of the given attachments that fit into one upload.
+// The returned function closes the files and removes them from the store.
+func (m *Module) openStoredAttachments(attachments []CachedAttachment) ([]*discordgo.File, func()) {
+	var files []*discordgo.File
+	var readers []io.ReadCloser
+	totalSize := 0
+
+	for _, attachment := range attachments {
+		if totalSize+attachment.Size > discordUploadLimitBytes {
+			continue
+		}
+		reader, err := m.attachments.Open(attachment.ID)
+		if err != nil {
+			continue
+		}
+		totalSize += attachment.Size
+		readers = append(readers, reader)
+		files = append(files, &discordgo.File{
+			Name:        attachment.Filename,
+			ContentType: attachment.ContentType,
+			Reader:      reader,
+		})
+	}
+
+	return files, func() {
+		for _, reader := range readers {
+			_ = reader.Close()
+		}
+		for _, attachment := range attachments {
+			m.attachments.Delete(attachment.ID)
+		}
+	}
+}
+
+func findRemovedAttachments(oldAttachments []CachedAttachment, newAttachments []*discordgo.MessageAttachment) []CachedAttachment {
+	var removed []CachedAttachment
+	for _, oldAttachment := range oldAttachments {
+		found := false
+		for _, newAttachment := range newAttachments {
+			if oldAttachment.ID == newAttachment.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			removed = append(removed, oldAttachment)
+		}
+	}
+	return removed
+}
+
+func formatAttachmentList(attachments []CachedAttachment) string {
+	if len(attachments) == 0 {
+		return "None"
+	}
+	var lines []string
+	for _, attachment := range attachments {
+		lines = append(lines, fmt.Sprintf("%s (%s, %s) %s", attachment.Filename, formatFileSize(attachment.Size), attachment.ContentType, attachment.URL))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatFileSize(size int) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/1024/1024)
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d B", size)
+}
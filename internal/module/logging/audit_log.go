@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+const (
+	// audit log entries are usually created shortly after the gateway event was dispatched
+	auditLogLookupDelay  = time.Second * 2
+	auditLogLookupWindow = time.Second * 15
+	auditLogLookupLimit  = 10
+)
+
+type auditLogAttribution struct {
+	ModeratorID       string
+	ModeratorFullName string
+	Reason            string
+}
+
+func (a *auditLogAttribution) addToLogData(data map[string]string) {
+	if a == nil {
+		data["moderator_id"] = ""
+		data["moderator_full_name"] = "Unknown"
+		data["reason"] = "No reason given"
+		return
+	}
+	data["moderator_id"] = a.ModeratorID
+	data["moderator_full_name"] = a.ModeratorFullName
+	data["reason"] = a.Reason
+}
+
+func (m *Module) fetchAuditLogEntries(guildID string, action discordgo.AuditLogAction) (*discordgo.GuildAuditLog, bool) {
+	time.Sleep(auditLogLookupDelay)
+
+	auditLog, err := m.discord.GuildAuditLog(guildID, "", "", int(action), auditLogLookupLimit)
+	if err != nil {
+		m.logger.Warn("Error fetching guild audit log", zap.String("guild", guildID), zap.Int("action", int(action)), zap.Error(err))
+		return nil, false
+	}
+	return auditLog, true
+}
+
+// findAuditLogAttribution returns the moderator responsible for the most recent audit log entry of the given action
+// targeting targetID, or nil if there was no such entry within the lookup window.
+func (m *Module) findAuditLogAttribution(guildID string, action discordgo.AuditLogAction, targetID string) *auditLogAttribution {
+	return m.findAuditLogAttributionForActions(guildID, targetID, action)
+}
+
+// findAuditLogAttributionForActions works like findAuditLogAttribution, but accepts entries of any of the given
+// actions, e.g. channel updates and permission overwrite changes.
+func (m *Module) findAuditLogAttributionForActions(guildID string, targetID string, actions ...discordgo.AuditLogAction) *auditLogAttribution {
+	queryAction := discordgo.AuditLogAction(0)
+	if len(actions) == 1 {
+		queryAction = actions[0]
+	}
+	auditLog, ok := m.fetchAuditLogEntries(guildID, queryAction)
+	if !ok {
+		return nil
+	}
+
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.TargetID != targetID || entry.ActionType == nil || !containsAuditLogAction(actions, *entry.ActionType) {
+			continue
+		}
+		createdAt, err := discordgo.SnowflakeTimestamp(entry.ID)
+		if err != nil || time.Since(createdAt) > auditLogLookupWindow {
+			continue
+		}
+		return newAuditLogAttribution(auditLog, entry)
+	}
+	return nil
+}
+
+// findAuditLogAttributionForChange works like findAuditLogAttribution, but only accepts entries changing the given key,
+// e.g. timeouts instead of any member update.
+func (m *Module) findAuditLogAttributionForChange(guildID string, action discordgo.AuditLogAction, targetID string, changeKey discordgo.AuditLogChangeKey) *auditLogAttribution {
+	auditLog, ok := m.fetchAuditLogEntries(guildID, action)
+	if !ok {
+		return nil
+	}
+
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.TargetID != targetID || entry.ActionType == nil || *entry.ActionType != action || !hasAuditLogChange(entry, changeKey) {
+			continue
+		}
+		createdAt, err := discordgo.SnowflakeTimestamp(entry.ID)
+		if err != nil || time.Since(createdAt) > auditLogLookupWindow {
+			continue
+		}
+		return newAuditLogAttribution(auditLog, entry)
+	}
+	return nil
+}
+
+func hasAuditLogChange(entry *discordgo.AuditLogEntry, changeKey discordgo.AuditLogChangeKey) bool {
+	for _, change := range entry.Changes {
+		if change.Key != nil && *change.Key == changeKey {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAuditLogAction(actions []discordgo.AuditLogAction, action discordgo.AuditLogAction) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// findMessageDeleteAttribution works like findAuditLogAttribution, but also accounts for Discord aggregating
+// multiple message deletions by the same moderator into one entry with an increasing count.
+func (m *Module) findMessageDeleteAttribution(guildID string, channelID string, authorID string) *auditLogAttribution {
+	auditLog, ok := m.fetchAuditLogEntries(guildID, discordgo.AuditLogActionMessageDelete)
+	if !ok {
+		return nil
+	}
+
+	m.auditLogMutex.Lock()
+	defer m.auditLogMutex.Unlock()
+
+	var attribution *auditLogAttribution
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.Options == nil {
+			continue
+		}
+		count, err := strconv.Atoi(entry.Options.Count)
+		if err != nil {
+			continue
+		}
+		lastCount, seen := m.messageDeleteEntryCounts[entry.ID]
+		m.messageDeleteEntryCounts[entry.ID] = count
+
+		if attribution != nil || entry.TargetID != authorID || entry.Options.ChannelID != channelID {
+			continue
+		}
+
+		if seen {
+			if count > lastCount {
+				attribution = newAuditLogAttribution(auditLog, entry)
+			}
+			continue
+		}
+		createdAt, err := discordgo.SnowflakeTimestamp(entry.ID)
+		if err == nil && time.Since(createdAt) <= auditLogLookupWindow {
+			attribution = newAuditLogAttribution(auditLog, entry)
+		}
+	}
+
+	// entries only get aggregated for a few minutes, so there is no need to remember older ones
+	for entryID := range m.messageDeleteEntryCounts {
+		createdAt, err := discordgo.SnowflakeTimestamp(entryID)
+		if err != nil || time.Since(createdAt) > time.Hour {
+			delete(m.messageDeleteEntryCounts, entryID)
+		}
+	}
+
+	return attribution
+}
+
+func newAuditLogAttribution(auditLog *discordgo.GuildAuditLog, entry *discordgo.AuditLogEntry) *auditLogAttribution {
+	attribution := auditLogAttribution{
+		ModeratorID:       entry.UserID,
+		ModeratorFullName: "Unknown",
+		Reason:            entry.Reason,
+	}
+	if len(attribution.Reason) == 0 {
+		attribution.Reason = "No reason given"
+	}
+	for _, user := range auditLog.Users {
+		if user.ID == entry.UserID {
+			attribution.ModeratorFullName = user.String()
+			break
+		}
+	}
+	return &attribution
+}
@@ -0,0 +1,142 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxAutocompleteChoices     = 25
+	maxAutocompleteNameLength  = 100
+	maxAutocompleteValueLength = 100
+)
+
+func (m *Module) handleLoggingAutocomplete(interaction *discordgo.Interaction) {
+	focused := findFocusedOption(interaction.ApplicationCommandData().Options)
+	if focused == nil {
+		return
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	switch focused.Name {
+	case CommandOptionLoggingType:
+		choices = logTypeChoices("", focused.StringValue(), nil)
+	case CommandOptionLoggingTypes:
+		// only the last element of the comma separated list is completed
+		input := focused.StringValue()
+		prefix := ""
+		query := input
+		if i := strings.LastIndex(input, ","); i >= 0 {
+			prefix = input[:i+1]
+			query = input[i+1:]
+		}
+		listed := make(map[LogType]bool)
+		for _, element := range strings.Split(prefix, ",") {
+			if logType, ok := ParseLogType(strings.TrimSpace(element)); ok {
+				listed[logType] = true
+			}
+		}
+		choices = logTypeChoices(prefix, query, listed)
+	default:
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func findFocusedOption(options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, option := range options {
+		if option.Focused {
+			return option
+		}
+		if focused := findFocusedOption(option.Options); focused != nil {
+			return focused
+		}
+	}
+	return nil
+}
+
+// logTypeChoices returns the logging types matching the query by name or value, best matches first. All types do not
+// fit into one list, so an empty query lists the categories, which narrow the choices down to their types once picked.
+func logTypeChoices(valuePrefix string, query string, exclude map[LogType]bool) []*discordgo.ApplicationCommandOptionChoice {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if len(query) == 0 {
+		return logTypeCategoryChoices(valuePrefix, exclude)
+	}
+
+	// matches are ranked by prefix matches of the type, then any matches of the type and then matches of the category
+	rankedLogTypes := make([][]LogType, 3)
+	for _, category := range logTypeCategories {
+		for _, logType := range category.LogTypes {
+			if exclude[logType] {
+				continue
+			}
+			name := strings.ToLower(logType.ToReadableString())
+			switch {
+			case strings.HasPrefix(name, query) || strings.HasPrefix(string(logType), query):
+				rankedLogTypes[0] = append(rankedLogTypes[0], logType)
+			case strings.Contains(name, query) || strings.Contains(string(logType), query):
+				rankedLogTypes[1] = append(rankedLogTypes[1], logType)
+			case strings.Contains(strings.ToLower(category.Name), query):
+				rankedLogTypes[2] = append(rankedLogTypes[2], logType)
+			}
+		}
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, logTypes := range rankedLogTypes {
+		for _, logType := range logTypes {
+			value := valuePrefix + string(logType)
+			if len(value) > maxAutocompleteValueLength {
+				return choices
+			}
+			name := logType.ToReadableString()
+			if len(valuePrefix) > 0 {
+				name = value
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  name,
+				Value: value,
+			})
+			if len(choices) == maxAutocompleteChoices {
+				return choices
+			}
+		}
+	}
+	return choices
+}
+
+func logTypeCategoryChoices(valuePrefix string, exclude map[LogType]bool) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, category := range logTypeCategories {
+		var names []string
+		for _, logType := range category.LogTypes {
+			if !exclude[logType] {
+				names = append(names, logType.ToReadableString())
+			}
+		}
+		value := valuePrefix + strings.ToLower(category.Name)
+		if len(names) == 0 || len(value) > maxAutocompleteValueLength {
+			continue
+		}
+		name := category.Name + ": " + strings.Join(names, ", ")
+		if utf8.RuneCountInString(name) > maxAutocompleteNameLength {
+			name = substringUTF8(name, 0, maxAutocompleteNameLength-1) + "…"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  name,
+			Value: value,
+		})
+	}
+	return choices
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"time"
+)
+
+const contentNotCached = "Not cached"
+
+// messageCachePolicy decides how long and in which detail messages of a guild are cached.
+type messageCachePolicy struct {
+	TTL          time.Duration
+	CacheContent bool
+	ChannelIDs   []string
+}
+
+func (m *Module) getMessageCachePolicy(guildID string) messageCachePolicy {
+	if policy, ok := m.cachePolicies.Get(guildID); ok {
+		return policy
+	}
+
+	guildConfig := m.getGuildLoggingConfig(guildID)
+	policy := messageCachePolicy{
+		TTL:          m.getMessageCacheTTL(guildConfig),
+		CacheContent: guildConfig.CacheContent == nil || *guildConfig.CacheContent,
+	}
+
+	var channels []GuildCacheChannel
+	result := m.db.Where(&GuildCacheChannel{GuildID: guildID}).Order("id").Find(&channels)
+	if result.Error != nil {
+		m.logger.Error("Error fetching cache channels from db", zap.String("guild", guildID), zap.Error(result.Error))
+		return policy
+	}
+	for _, channel := range channels {
+		policy.ChannelIDs = append(policy.ChannelIDs, channel.ChannelID)
+	}
+	m.cachePolicies.Set(guildID, policy)
+	return policy
+}
+
+// getMessageCacheTTL returns the ttl configured by the guild, limited by the maximum of the bot config.
+func (m *Module) getMessageCacheTTL(guildConfig GuildLoggingConfig) time.Duration {
+	if guildConfig.CacheTTLMinutes == nil {
+		return time.Minute * time.Duration(m.config.Cache.MessageTTLMinutes)
+	}
+	ttl := time.Minute * time.Duration(*guildConfig.CacheTTLMinutes)
+	if maxTTL := m.config.Cache.MaxMessageTTL(); ttl > maxTTL {
+		return maxTTL
+	}
+	return ttl
+}
+
+// cachesContentOf reports whether the content of messages in the channel may be cached. Channels match the list
+// directly, through their category or as parent of a thread.
+func (m *Module) cachesContentOf(policy messageCachePolicy, channelID string) bool {
+	if !policy.CacheContent {
+		return false
+	}
+	if len(policy.ChannelIDs) == 0 {
+		return true
+	}
+	subject := m.newChannelIgnoreSubject(channelID)
+	for _, cachedChannelID := range policy.ChannelIDs {
+		if cachedChannelID == subject.ChannelID || cachedChannelID == subject.ParentChannelID || cachedChannelID == subject.CategoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// omitContent removes everything written by the author and keeps what is needed to log edits, deletions and ghost
+// pings.
+func (cm *CachedMessage) omitContent() {
+	cm.Content = ""
+	cm.Attachments = nil
+	cm.Stickers = nil
+	cm.Embeds = nil
+	cm.Revisions = nil
+	cm.DroppedRevisions = 0
+	cm.ContentOmitted = true
+}
+
+func (cm *CachedMessage) contentForLog() string {
+	if cm.ContentOmitted {
+		return contentNotCached
+	}
+	return cm.Content
+}
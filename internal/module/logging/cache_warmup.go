@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/cache"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	maxCacheWarmupMessages = 1000
+	// discords limit for a single channel messages request
+	channelMessagesPageSize = 100
+)
+
+func (m *Module) registerCacheWarmup() {
+	for _, guild := range m.getStateGuilds() {
+		go m.warmUpGuildCache(guild.ID)
+	}
+
+	// guilds are still being received while the modules start
+	m.discord.AddHandler(func(_ *discordgo.Session, guildCreate *discordgo.GuildCreate) {
+		go m.warmUpGuildCache(guildCreate.ID)
+	})
+}
+
+func (m *Module) getCacheWarmupMessages(guildID string) int {
+	guildConfig := m.getGuildLoggingConfig(guildID)
+	if guildConfig.CacheWarmupMessages == nil {
+		return m.config.Logging.CacheWarmupMessages
+	}
+	return *guildConfig.CacheWarmupMessages
+}
+
+// warmUpGuildCache caches the latest messages of every channel once per start, so edits and deletions of messages sent
+// before the bot came up can be logged. Guilds are warmed up one after another to stay within the rate limits.
+// Attachments are not downloaded, since most of them will never be needed.
+func (m *Module) warmUpGuildCache(guildID string) {
+	m.cacheWarmupMutex.Lock()
+	defer m.cacheWarmupMutex.Unlock()
+
+	if _, ok := m.warmedUpGuilds.Get(guildID); ok {
+		return
+	}
+	// channels of unavailable guilds are not known yet, a guild create event follows once they are
+	if guild, err := m.discord.State.Guild(guildID); err != nil || guild.Unavailable {
+		return
+	}
+	m.warmedUpGuilds.Set(guildID, true)
+
+	limit := m.getCacheWarmupMessages(guildID)
+	if limit <= 0 {
+		return
+	}
+	if !m.isLoggingEnabled(guildID, MessageEdit) && !m.isLoggingEnabled(guildID, MessageDelete) && !m.isLoggingEnabled(guildID, MessageBulkDelete) {
+		return
+	}
+
+	policy := m.getMessageCachePolicy(guildID)
+	channelIDs := m.getWarmupChannelIDs(guildID)
+	m.logger.Info("Warming up message cache", zap.String("guild", guildID), zap.Int("channels", len(channelIDs)), zap.Int("messagesPerChannel", limit))
+
+	start := time.Now()
+	total := 0
+	for i, channelID := range channelIDs {
+		count, err := m.warmUpChannelCache(guildID, channelID, limit, policy)
+		total += count
+		if err != nil {
+			m.logger.Warn("Error fetching channel messages for cache warm-up", zap.String("guild", guildID), zap.String("channel", channelID), zap.Error(err))
+			continue
+		}
+		m.logger.Info("Warmed up channel message cache", zap.String("guild", guildID), zap.String("channel", channelID), zap.Int("messages", count), zap.Int("progress", i+1), zap.Int("channels", len(channelIDs)))
+	}
+
+	m.logger.Info("Message cache warm-up completed", zap.String("guild", guildID), zap.Int("messages", total), zap.Duration("duration", time.Since(start)))
+}
+
+// getWarmupChannelIDs returns all text channels and active threads the bot can read the history of.
+func (m *Module) getWarmupChannelIDs(guildID string) []string {
+	guild, err := m.discord.State.Guild(guildID)
+	if err != nil {
+		return nil
+	}
+	// the state is updated by the gateway concurrently, State.Guild must not be called while holding the lock
+	var channels []*discordgo.Channel
+	m.discord.State.RLock()
+	channels = append(channels, guild.Channels...)
+	channels = append(channels, guild.Threads...)
+	m.discord.State.RUnlock()
+
+	var channelIDs []string
+	for _, channel := range channels {
+		switch channel.Type {
+		case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildVoice,
+			discordgo.ChannelTypeGuildPublicThread, discordgo.ChannelTypeGuildPrivateThread, discordgo.ChannelTypeGuildNewsThread:
+		default:
+			continue
+		}
+		permissions, err := m.discord.State.UserChannelPermissions(m.discord.State.User.ID, channel.ID)
+		required := int64(discordgo.PermissionViewChannel | discordgo.PermissionReadMessageHistory)
+		if err != nil || permissions&required != required {
+			continue
+		}
+		channelIDs = append(channelIDs, channel.ID)
+	}
+	return channelIDs
+}
+
+func (m *Module) warmUpChannelCache(guildID string, channelID string, limit int, policy messageCachePolicy) (int, error) {
+	count := 0
+	beforeID := ""
+	for count < limit {
+		pageSize := limit - count
+		if pageSize > channelMessagesPageSize {
+			pageSize = channelMessagesPageSize
+		}
+
+		messages, err := m.discord.ChannelMessages(channelID, pageSize, beforeID, "", "")
+		if err != nil {
+			return count, err
+		}
+		for _, msg := range messages {
+			// messages fetched over the api don't contain the guild
+			msg.GuildID = guildID
+			if m.isMessageCached(msg.ID) {
+				continue
+			}
+			m.cacheMessage(msg, policy)
+		}
+		count += len(messages)
+
+		if len(messages) < pageSize {
+			break
+		}
+		beforeID = messages[len(messages)-1].ID
+	}
+	return count, nil
+}
+
+// isMessageCached keeps the warm-up from replacing entries that survived a restart, they can contain revisions and
+// content from before an edit. Messages that could not be looked up are treated as cached, to not overwrite them.
+func (m *Module) isMessageCached(messageID string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := m.messages.Get(ctx, messageID)
+	return !errors.Is(err, cache.ErrMessageNotFound)
+}
@@ -0,0 +1,306 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strconv"
+	"strings"
+)
+
+var (
+	channelTypeReadableStringsMap = map[discordgo.ChannelType]string{
+		discordgo.ChannelTypeGuildText:          "Text Channel",
+		discordgo.ChannelTypeGuildVoice:         "Voice Channel",
+		discordgo.ChannelTypeGuildCategory:      "Category",
+		discordgo.ChannelTypeGuildNews:          "Announcement Channel",
+		discordgo.ChannelTypeGuildStore:         "Store Channel",
+		discordgo.ChannelTypeGuildNewsThread:    "Announcement Thread",
+		discordgo.ChannelTypeGuildPublicThread:  "Thread",
+		discordgo.ChannelTypeGuildPrivateThread: "Private Thread",
+		discordgo.ChannelTypeGuildStageVoice:    "Stage Channel",
+		discordgo.ChannelTypeGuildForum:         "Forum Channel",
+	}
+)
+
+type channelSnapshot struct {
+	GuildID              string
+	Name                 string
+	Topic                string
+	Type                 discordgo.ChannelType
+	NSFW                 bool
+	RateLimitPerUser     int
+	ParentID             string
+	Archived             bool
+	Locked               bool
+	PermissionOverwrites []discordgo.PermissionOverwrite
+}
+
+func newChannelSnapshot(channel *discordgo.Channel) channelSnapshot {
+	snapshot := channelSnapshot{
+		GuildID:          channel.GuildID,
+		Name:             channel.Name,
+		Topic:            channel.Topic,
+		Type:             channel.Type,
+		NSFW:             channel.NSFW,
+		RateLimitPerUser: channel.RateLimitPerUser,
+		ParentID:         channel.ParentID,
+	}
+	if channel.ThreadMetadata != nil {
+		snapshot.Archived = channel.ThreadMetadata.Archived
+		snapshot.Locked = channel.ThreadMetadata.Locked
+	}
+	for _, overwrite := range channel.PermissionOverwrites {
+		snapshot.PermissionOverwrites = append(snapshot.PermissionOverwrites, *overwrite)
+	}
+	return snapshot
+}
+
+func (m *Module) registerChannelListeners() {
+	for _, guild := range m.getStateGuilds() {
+		m.snapshotGuildChannels(guild)
+	}
+
+	m.discord.AddHandler(func(_ *discordgo.Session, guildCreate *discordgo.GuildCreate) {
+		m.snapshotGuildChannels(guildCreate.Guild)
+	})
+	m.discord.AddHandler(m.handleChannelCreate)
+	m.discord.AddHandler(m.handleChannelUpdate)
+	m.discord.AddHandler(m.handleChannelDelete)
+	m.discord.AddHandler(m.handleThreadCreate)
+	m.discord.AddHandler(m.handleThreadUpdate)
+	m.discord.AddHandler(m.handleThreadDelete)
+}
+
+func (m *Module) snapshotGuildChannels(guild *discordgo.Guild) {
+	for _, channel := range guild.Channels {
+		m.channelSnapshots.Set(channel.ID, newChannelSnapshot(channel))
+	}
+	for _, thread := range guild.Threads {
+		m.channelSnapshots.Set(thread.ID, newChannelSnapshot(thread))
+	}
+}
+
+func (m *Module) handleChannelCreate(_ *discordgo.Session, channelCreate *discordgo.ChannelCreate) {
+	m.logChannelCreation(channelCreate.Channel, discordgo.AuditLogActionChannelCreate)
+}
+
+func (m *Module) handleThreadCreate(_ *discordgo.Session, threadCreate *discordgo.ThreadCreate) {
+	// thread creates are also sent when the bot gets added to an existing thread
+	if !threadCreate.NewlyCreated {
+		m.channelSnapshots.Set(threadCreate.ID, newChannelSnapshot(threadCreate.Channel))
+		return
+	}
+	m.logChannelCreation(threadCreate.Channel, discordgo.AuditLogActionThreadCreate)
+}
+
+func (m *Module) logChannelCreation(channel *discordgo.Channel, action discordgo.AuditLogAction) {
+	snapshot := newChannelSnapshot(channel)
+	m.channelSnapshots.Set(channel.ID, snapshot)
+
+	if len(channel.GuildID) == 0 || !m.isLoggingEnabled(channel.GuildID, ChannelCreate) {
+		return
+	}
+
+	data := m.channelLogData(channel.ID, snapshot)
+	m.findAuditLogAttribution(channel.GuildID, action, channel.ID).addToLogData(data)
+	if action == discordgo.AuditLogActionThreadCreate && data["moderator_id"] == "" && len(channel.OwnerID) > 0 {
+		// threads created from messages do not always show up in the audit log
+		data["moderator_id"] = channel.OwnerID
+		if owner, err := m.discord.State.Member(channel.GuildID, channel.OwnerID); err == nil && owner.User != nil {
+			data["moderator_full_name"] = owner.User.String()
+		}
+	}
+
+	m.sendLogToDiscord(channel.GuildID, ChannelCreate, data)
+}
+
+func (m *Module) handleChannelUpdate(_ *discordgo.Session, channelUpdate *discordgo.ChannelUpdate) {
+	m.logChannelUpdate(channelUpdate.Channel,
+		discordgo.AuditLogActionChannelUpdate,
+		discordgo.AuditLogActionChannelOverwriteCreate,
+		discordgo.AuditLogActionChannelOverwriteUpdate,
+		discordgo.AuditLogActionChannelOverwriteDelete,
+	)
+}
+
+func (m *Module) handleThreadUpdate(_ *discordgo.Session, threadUpdate *discordgo.ThreadUpdate) {
+	m.logChannelUpdate(threadUpdate.Channel, discordgo.AuditLogActionThreadUpdate)
+}
+
+func (m *Module) logChannelUpdate(channel *discordgo.Channel, actions ...discordgo.AuditLogAction) {
+	snapshot := newChannelSnapshot(channel)
+	oldSnapshot, found := m.channelSnapshots.Get(channel.ID)
+	m.channelSnapshots.Set(channel.ID, snapshot)
+
+	if !found || len(channel.GuildID) == 0 || !m.isLoggingEnabled(channel.GuildID, ChannelUpdate) {
+		return
+	}
+
+	changes := m.findChannelChanges(oldSnapshot, snapshot)
+	if len(changes) == 0 {
+		// position changes and other irrelevant updates
+		return
+	}
+
+	data := m.channelLogData(channel.ID, snapshot)
+	data["changes"] = strings.Join(changes, "\n")
+	m.findAuditLogAttributionForActions(channel.GuildID, channel.ID, actions...).addToLogData(data)
+
+	m.sendLogToDiscord(channel.GuildID, ChannelUpdate, data)
+}
+
+func (m *Module) handleChannelDelete(_ *discordgo.Session, channelDelete *discordgo.ChannelDelete) {
+	m.logChannelDeletion(channelDelete.Channel, discordgo.AuditLogActionChannelDelete)
+}
+
+func (m *Module) handleThreadDelete(_ *discordgo.Session, threadDelete *discordgo.ThreadDelete) {
+	m.logChannelDeletion(threadDelete.Channel, discordgo.AuditLogActionThreadDelete)
+}
+
+func (m *Module) logChannelDeletion(channel *discordgo.Channel, action discordgo.AuditLogAction) {
+	// thread deletes only contain the ids, so prefer the last known snapshot
+	snapshot, found := m.channelSnapshots.Get(channel.ID)
+	if !found {
+		snapshot = newChannelSnapshot(channel)
+	}
+	m.channelSnapshots.Delete(channel.ID)
+
+	if len(channel.GuildID) == 0 || !m.isLoggingEnabled(channel.GuildID, ChannelDelete) {
+		return
+	}
+
+	data := m.channelLogData(channel.ID, snapshot)
+	m.findAuditLogAttribution(channel.GuildID, action, channel.ID).addToLogData(data)
+
+	m.sendLogToDiscord(channel.GuildID, ChannelDelete, data)
+}
+
+func (m *Module) channelLogData(channelID string, snapshot channelSnapshot) map[string]string {
+	return map[string]string{
+		"channel_id":   channelID,
+		"channel_name": snapshot.Name,
+		"channel_type": formatChannelType(snapshot.Type),
+		"parent_id":    snapshot.ParentID,
+		"parent_name":  m.getParentName(snapshot.ParentID),
+	}
+}
+
+func (m *Module) getParentName(parentID string) string {
+	if len(parentID) == 0 {
+		return "None"
+	}
+	if parent, ok := m.channelSnapshots.Get(parentID); ok {
+		return parent.Name
+	}
+	return m.getChannelName(parentID)
+}
+
+func (m *Module) findChannelChanges(oldSnapshot channelSnapshot, newSnapshot channelSnapshot) []string {
+	var changes []string
+
+	if oldSnapshot.Name != newSnapshot.Name {
+		changes = append(changes, "Name: `"+oldSnapshot.Name+"` → `"+newSnapshot.Name+"`")
+	}
+	if oldSnapshot.Topic != newSnapshot.Topic {
+		changes = append(changes, "Topic: `"+formatOptionalString(oldSnapshot.Topic)+"` → `"+formatOptionalString(newSnapshot.Topic)+"`")
+	}
+	if oldSnapshot.RateLimitPerUser != newSnapshot.RateLimitPerUser {
+		changes = append(changes, "Slowmode: "+formatSlowmode(oldSnapshot.RateLimitPerUser)+" → "+formatSlowmode(newSnapshot.RateLimitPerUser))
+	}
+	if oldSnapshot.NSFW != newSnapshot.NSFW {
+		changes = append(changes, "NSFW: "+strconv.FormatBool(oldSnapshot.NSFW)+" → "+strconv.FormatBool(newSnapshot.NSFW))
+	}
+	if oldSnapshot.ParentID != newSnapshot.ParentID {
+		changes = append(changes, "Category: "+m.getParentName(oldSnapshot.ParentID)+" → "+m.getParentName(newSnapshot.ParentID))
+	}
+	if oldSnapshot.Type != newSnapshot.Type {
+		changes = append(changes, "Type: "+formatChannelType(oldSnapshot.Type)+" → "+formatChannelType(newSnapshot.Type))
+	}
+	if oldSnapshot.Archived != newSnapshot.Archived {
+		changes = append(changes, "Archived: "+strconv.FormatBool(oldSnapshot.Archived)+" → "+strconv.FormatBool(newSnapshot.Archived))
+	}
+	if oldSnapshot.Locked != newSnapshot.Locked {
+		changes = append(changes, "Locked: "+strconv.FormatBool(oldSnapshot.Locked)+" → "+strconv.FormatBool(newSnapshot.Locked))
+	}
+
+	return append(changes, m.findPermissionOverwriteChanges(newSnapshot.GuildID, oldSnapshot.PermissionOverwrites, newSnapshot.PermissionOverwrites)...)
+}
+
+func (m *Module) findPermissionOverwriteChanges(guildID string, oldOverwrites []discordgo.PermissionOverwrite, newOverwrites []discordgo.PermissionOverwrite) []string {
+	var changes []string
+
+	findOverwrite := func(overwrites []discordgo.PermissionOverwrite, id string) (discordgo.PermissionOverwrite, bool) {
+		for _, overwrite := range overwrites {
+			if overwrite.ID == id {
+				return overwrite, true
+			}
+		}
+		return discordgo.PermissionOverwrite{}, false
+	}
+
+	for _, newOverwrite := range newOverwrites {
+		oldOverwrite, found := findOverwrite(oldOverwrites, newOverwrite.ID)
+		if found && oldOverwrite.Allow == newOverwrite.Allow && oldOverwrite.Deny == newOverwrite.Deny {
+			continue
+		}
+
+		prefix := "Permissions for " + m.formatOverwriteTarget(guildID, newOverwrite) + ": "
+		if !found {
+			prefix = "Added permissions for " + m.formatOverwriteTarget(guildID, newOverwrite) + ": "
+		}
+
+		var parts []string
+		if allowChanges := formatPermissionDifferences(oldOverwrite.Allow, newOverwrite.Allow); len(allowChanges) > 0 {
+			parts = append(parts, "allowed "+allowChanges)
+		}
+		if denyChanges := formatPermissionDifferences(oldOverwrite.Deny, newOverwrite.Deny); len(denyChanges) > 0 {
+			parts = append(parts, "denied "+denyChanges)
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "no permissions set")
+		}
+		changes = append(changes, prefix+strings.Join(parts, "; "))
+	}
+
+	for _, oldOverwrite := range oldOverwrites {
+		if _, found := findOverwrite(newOverwrites, oldOverwrite.ID); !found {
+			changes = append(changes, "Removed permissions for "+m.formatOverwriteTarget(guildID, oldOverwrite))
+		}
+	}
+
+	return changes
+}
+
+func (m *Module) formatOverwriteTarget(guildID string, overwrite discordgo.PermissionOverwrite) string {
+	if overwrite.Type == discordgo.PermissionOverwriteTypeMember {
+		return "<@" + overwrite.ID + ">"
+	}
+	if overwrite.ID == guildID {
+		return "@everyone"
+	}
+	role, err := m.discord.State.Role(guildID, overwrite.ID)
+	if err != nil {
+		return "Unknown Role"
+	}
+	return "@" + role.Name
+}
+
+func formatChannelType(channelType discordgo.ChannelType) string {
+	if str, ok := channelTypeReadableStringsMap[channelType]; ok {
+		return str
+	}
+	return "Channel"
+}
+
+func formatSlowmode(seconds int) string {
+	if seconds == 0 {
+		return "off"
+	}
+	return strconv.Itoa(seconds) + "s"
+}
+
+func formatOptionalString(str string) string {
+	if len(str) == 0 {
+		return "None"
+	}
+	return str
+}
@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+func (m *Module) handleLoggingCacheCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if _, ok := optionMap[CommandOptionCacheSettingsCmd]; ok {
+		m.handleLoggingCacheSettingsCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionCacheAddChannelCmd]; ok {
+		m.handleLoggingCacheAddChannelCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionCacheRemoveChannelCmd]; ok {
+		m.handleLoggingCacheRemoveChannelCommand(interaction, optionMap)
+	}
+}
+
+func (m *Module) handleLoggingCacheSettingsCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	ttlOption, hasTTL := optionMap[CommandOptionCacheTTL]
+	contentOption, hasContent := optionMap[CommandOptionCacheContent]
+	if !hasTTL && !hasContent {
+		respond(m.formatMessageCachePolicy(interaction.GuildID))
+		return
+	}
+
+	var ttlMinutes int
+	if hasTTL {
+		ttlMinutes = int(ttlOption.IntValue())
+		maxTTLMinutes := int(m.config.Cache.MaxMessageTTL().Minutes())
+		if ttlMinutes < 1 || ttlMinutes > maxTTLMinutes {
+			respond("The cache duration has to be between 1 and " + strconv.Itoa(maxTTLMinutes) + " minutes.")
+			return
+		}
+	}
+
+	err := m.updateGuildLoggingConfig(interaction.GuildID, func(guildConfig *GuildLoggingConfig) {
+		if hasTTL {
+			guildConfig.CacheTTLMinutes = &ttlMinutes
+		}
+		if hasContent {
+			cacheContent := contentOption.BoolValue()
+			guildConfig.CacheContent = &cacheContent
+		}
+	})
+	if err != nil {
+		m.logger.Error("Error updating guild logging config in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	respond("Message cache settings updated, they apply to messages cached from now on.\n" + m.formatMessageCachePolicy(interaction.GuildID))
+}
+
+func (m *Module) handleLoggingCacheAddChannelCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	channelOption, ok := optionMap[CommandOptionChannel]
+	if !ok {
+		respond("Channel missing or invalid.")
+		return
+	}
+	channelID, details := m.parseChannelOption(interaction.GuildID, channelOption)
+	if len(details) > 0 {
+		respond(details)
+		return
+	}
+
+	var count int64
+	dbResult := m.db.Model(&GuildCacheChannel{}).Where("guild_id = ? AND channel_id = ?", interaction.GuildID, channelID).Count(&count)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching cache channels from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if count > 0 {
+		respond("The content of <#" + channelID + "> is already cached.")
+		return
+	}
+
+	dbResult = m.db.Create(&GuildCacheChannel{GuildID: interaction.GuildID, ChannelID: channelID})
+	m.cachePolicies.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error creating cache channel in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	respond("Message content of <#" + channelID + "> will be cached. Channels that are not listed are cached without content.\n" + m.formatMessageCachePolicy(interaction.GuildID))
+}
+
+func (m *Module) handleLoggingCacheRemoveChannelCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	channelOption, ok := optionMap[CommandOptionChannel]
+	if !ok || channelOption.Type != discordgo.ApplicationCommandOptionChannel {
+		respond("Channel missing or invalid.")
+		return
+	}
+	// the channel could have been deleted already, so the raw id is used
+	channelID := channelOption.Value.(string)
+
+	dbResult := m.db.Where("guild_id = ? AND channel_id = ?", interaction.GuildID, channelID).Delete(&GuildCacheChannel{})
+	m.cachePolicies.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error deleting cache channel from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if dbResult.RowsAffected == 0 {
+		respond("<#" + channelID + "> is not in the list of cached channels.")
+		return
+	}
+
+	respond("Removed <#" + channelID + "> from the cached channels.\n" + m.formatMessageCachePolicy(interaction.GuildID))
+}
+
+func (m *Module) formatMessageCachePolicy(guildID string) string {
+	policy := m.getMessageCachePolicy(guildID)
+
+	content := "Message content is not cached."
+	if policy.CacheContent {
+		if len(policy.ChannelIDs) == 0 {
+			content = "Message content is cached in all channels."
+		} else {
+			var channels []string
+			for _, channelID := range policy.ChannelIDs {
+				channels = append(channels, "<#"+channelID+">")
+			}
+			content = "Message content is cached in " + strings.Join(channels, ", ") + "."
+		}
+	}
+
+	return "Messages are cached for " + strconv.Itoa(int(policy.TTL.Minutes())) + " minutes. " + content
+}
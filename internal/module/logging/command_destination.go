@@ -0,0 +1,259 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"go.uber.org/zap"
+	"time"
+)
+
+func (m *Module) handleLoggingDestinationCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if _, ok := optionMap[CommandOptionDestinationAddCmd]; ok {
+		m.handleLoggingDestinationAddCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionDestinationRemoveCmd]; ok {
+		m.handleLoggingDestinationRemoveCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionDestinationListCmd]; ok {
+		m.handleLoggingDestinationListCommand(interaction, optionMap)
+	}
+}
+
+func (m *Module) handleLoggingDestinationAddCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error adding logging destination", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	logType, details := parseLogTypeOption(optionMap)
+	if len(details) > 0 {
+		handleError(details)
+		return
+	}
+
+	channelOption, ok := optionMap[CommandOptionChannel]
+	if !ok {
+		handleError("Channel missing.")
+		return
+	}
+	channelID, details := m.parseChannelOption(interaction.GuildID, channelOption)
+	if len(details) > 0 {
+		handleError(details)
+		return
+	}
+
+	destination := GuildLoggingDestination{
+		GuildID:    interaction.GuildID,
+		LogType:    logType,
+		ChannelID:  channelID,
+		Enabled:    true,
+		Format:     defaultLoggingFormats[logType],
+		OutputMode: OutputModeText,
+	}
+
+	if formatOption, ok := optionMap[CommandOptionFormat]; ok {
+		destination.Format = formatOption.StringValue()
+		if err := validateLogFormat(logType, destination.Format); err != nil {
+			handleError("The format is invalid: " + err.Error() + "\nAvailable placeholders: " + formatPlaceholderList(logType))
+			return
+		}
+	}
+	if outputModeOption, ok := optionMap[CommandOptionOutputMode]; ok {
+		destination.OutputMode, details = parseOutputModeOption(outputModeOption)
+		if len(details) > 0 {
+			handleError(details)
+			return
+		}
+	}
+
+	var count int64
+	dbResult := m.db.Model(&GuildLoggingDestination{}).Where(&GuildLoggingDestination{GuildID: interaction.GuildID, LogType: logType, ChannelID: channelID}).Count(&count)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching logging destinations from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if count > 0 {
+		handleError("This channel is already a destination for this logging type.")
+		return
+	}
+
+	dbResult = m.db.Create(&destination)
+	if dbResult.Error != nil {
+		m.logger.Error("Error creating logging destination in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if logType == MemberJoin {
+		// uses of invites are not tracked while join logging is disabled
+		go m.refreshInviteSnapshot(interaction.GuildID)
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Type:  discordgo.EmbedTypeRich,
+					Title: "Logging Destination added!",
+					Fields: []*discordgo.MessageEmbedField{
+						{
+							Name:  "Type",
+							Value: logType.ToReadableString(),
+						},
+						{
+							Name:  "Destination",
+							Value: formatDestination(destination),
+						},
+						{
+							Name:  "Format",
+							Value: destination.Format,
+						},
+					},
+					Color:     util.EmbedColorOK,
+					Timestamp: time.Now().Format(time.RFC3339),
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: "gowlbot " + util.GetVersionString(),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingDestinationRemoveCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error removing logging destination", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	logType, details := parseLogTypeOption(optionMap)
+	if len(details) > 0 {
+		handleError(details)
+		return
+	}
+
+	channelOption, ok := optionMap[CommandOptionChannel]
+	if !ok || channelOption.Type != discordgo.ApplicationCommandOptionChannel {
+		handleError("Channel missing or invalid.")
+		return
+	}
+	// deleted channels can't be resolved anymore, so the raw id is used
+	channelID := channelOption.Value.(string)
+
+	dbResult := m.db.Where(&GuildLoggingDestination{GuildID: interaction.GuildID, LogType: logType, ChannelID: channelID}).Delete(&GuildLoggingDestination{})
+	if dbResult.Error != nil {
+		m.logger.Error("Error deleting logging destination from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if dbResult.RowsAffected == 0 {
+		handleError("This channel is not a destination for this logging type.")
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: logType.ToReadableString() + " logs will no longer be sent to <#" + channelID + ">.",
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingDestinationListCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	query := GuildLoggingDestination{GuildID: interaction.GuildID}
+	if _, ok := optionMap[CommandOptionLoggingType]; ok {
+		logType, details := parseLogTypeOption(optionMap)
+		if len(details) > 0 {
+			m.logger.Error("Error parsing logging destination list", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+			err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: details,
+				},
+			})
+			if err != nil {
+				m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+			}
+			return
+		}
+		query.LogType = logType
+	}
+
+	var destinations []GuildLoggingDestination
+	result := m.db.Where(&query).Order("log_type").Order("id").Find(&destinations)
+
+	if result.Error != nil {
+		m.logger.Error("Error fetching logging destinations", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(result.Error))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "There was an error fetching the logging destinations for this server.",
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+		}
+		return
+	}
+
+	var embedFields []*discordgo.MessageEmbedField
+	for i := 0; i < len(destinations); {
+		end := i
+		for end < len(destinations) && destinations[end].LogType == destinations[i].LogType {
+			end++
+		}
+		embedFields = append(embedFields, &discordgo.MessageEmbedField{
+			Name:  destinations[i].LogType.ToReadableString(),
+			Value: formatDestinationList(destinations[i:end]),
+		})
+		i = end
+	}
+	if len(embedFields) == 0 {
+		embedFields = append(embedFields, &discordgo.MessageEmbedField{
+			Name:  "No destinations configured",
+			Value: "Add one with `/logging destination add`.",
+		})
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Type:      discordgo.EmbedTypeRich,
+					Title:     "Logging Destinations",
+					Fields:    embedFields,
+					Color:     util.EmbedColorInfo,
+					Timestamp: time.Now().Format(time.RFC3339),
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: "gowlbot " + util.GetVersionString(),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// leaves room for the rest of the multipart request
+	exportChunkSizeBytes = discordUploadLimitBytes - 64*1024
+	maxExportFiles       = 5
+)
+
+var errExportTooLarge = errors.New("export exceeds the maximum number of files")
+
+func (m *Module) handleLoggingExportCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	formatOption, ok := optionMap[CommandOptionExportFormat]
+	if !ok {
+		handleError("Export format missing.")
+		return
+	}
+	format, ok := ParseExportFormat(formatOption.StringValue())
+	if !ok {
+		handleError("Unknown export format.")
+		return
+	}
+
+	query := LogRecordQuery{GuildID: interaction.GuildID}
+	if logTypesOption, ok := optionMap[CommandOptionLoggingTypes]; ok {
+		logTypes, details := parseLogTypeList(logTypesOption.StringValue())
+		if len(details) > 0 {
+			handleError(details)
+			return
+		}
+		query.LogTypes = logTypes
+	}
+
+	now := time.Now()
+	var err error
+	if afterOption, ok := optionMap[CommandOptionAfter]; ok {
+		query.After, err = ParseTimeFilter(afterOption.StringValue(), now)
+		if err != nil {
+			handleError("Invalid `after` value: " + err.Error())
+			return
+		}
+	}
+	if beforeOption, ok := optionMap[CommandOptionBefore]; ok {
+		query.Before, err = ParseTimeFilter(beforeOption.StringValue(), now)
+		if err != nil {
+			handleError("Invalid `before` value: " + err.Error())
+			return
+		}
+	}
+
+	// large exports take longer than the three seconds discord waits for a response
+	err = m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		return
+	}
+
+	editResponse := func(content string, files []*discordgo.File) {
+		_, err := m.discord.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{
+			Content: &content,
+			Files:   files,
+		})
+		if err != nil {
+			m.logger.Error("Error editing interaction response", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	var chunks []*bytes.Buffer
+	count, err := ExportLogRecords(m.db, query, format, exportChunkSizeBytes, func() (io.Writer, error) {
+		if len(chunks) == maxExportFiles {
+			return nil, errExportTooLarge
+		}
+		chunk := &bytes.Buffer{}
+		chunks = append(chunks, chunk)
+		return chunk, nil
+	})
+	if errors.Is(err, errExportTooLarge) {
+		editResponse("The export is larger than "+strconv.Itoa(maxExportFiles)+" files, please choose a shorter time range or fewer logging types.", nil)
+		return
+	}
+	if err != nil {
+		m.logger.Error("Error exporting log records", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		editResponse("An internal error occurred. ["+interaction.ID+"]", nil)
+		return
+	}
+
+	// every file gets its own message, since the upload limit is shared by all files of a message
+	fileName := func(i int) string {
+		name := "logs-" + interaction.GuildID + "-" + now.UTC().Format("20060102-150405")
+		if len(chunks) > 1 {
+			name += "-" + strconv.Itoa(i+1)
+		}
+		return name + "." + format.FileExtension()
+	}
+	editResponse("Exported "+strconv.Itoa(count)+" log records in "+strconv.Itoa(len(chunks))+" file(s).", []*discordgo.File{
+		{Name: fileName(0), Reader: chunks[0]},
+	})
+	for i, chunk := range chunks[1:] {
+		_, err = m.discord.FollowupMessageCreate(interaction, false, &discordgo.WebhookParams{
+			Files: []*discordgo.File{{Name: fileName(i + 1), Reader: chunk}},
+			Flags: discordgo.MessageFlagsEphemeral,
+		})
+		if err != nil {
+			m.logger.Error("Error sending export follow-up message", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Int("part", i+2), zap.Error(err))
+			return
+		}
+	}
+}
+
+// parseLogTypeList parses a comma separated list of logging types.
+func parseLogTypeList(str string) ([]LogType, string) {
+	var logTypes []LogType
+	for _, part := range strings.Split(str, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		logType, ok := ParseLogType(part)
+		if !ok {
+			return nil, "Unknown logging type `" + part + "`."
+		}
+		logTypes = append(logTypes, logType)
+	}
+	return logTypes, ""
+}
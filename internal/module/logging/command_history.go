@@ -0,0 +1,171 @@
+package logging
+
+import (
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	historyPageSize       = 5
+	historyCustomIDPrefix = "logging-history:"
+)
+
+func (m *Module) handleEditHistoryCommand(interaction *discordgo.Interaction, messageID string) {
+	respond := func(response *discordgo.InteractionResponseData) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: response,
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	response, ok := m.buildEditHistoryResponse(interaction.GuildID, messageID, 0)
+	if !ok {
+		respond(&discordgo.InteractionResponseData{
+			Content: "This message was not found in the bots cache.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return
+	}
+	respond(response)
+}
+
+func (m *Module) handleEditHistoryPageButton(interaction *discordgo.Interaction, customID string) {
+	respond := func(response *discordgo.InteractionResponseData) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: response,
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	messageID, pageStr, ok := strings.Cut(strings.TrimPrefix(customID, historyCustomIDPrefix), ":")
+	page, err := strconv.Atoi(pageStr)
+	if !ok || err != nil || page < 0 {
+		m.logger.Warn("Invalid edit history button", zap.String("guild", interaction.GuildID), zap.String("customID", customID))
+		return
+	}
+
+	response, ok := m.buildEditHistoryResponse(interaction.GuildID, messageID, page)
+	if !ok {
+		respond(&discordgo.InteractionResponseData{
+			Content:    "This message is no longer in the bots cache.",
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		})
+		return
+	}
+	respond(response)
+}
+
+// buildEditHistoryResponse lists the cached revisions of a message, oldest first. It returns false if the message is
+// not cached or not part of the guild.
+func (m *Module) buildEditHistoryResponse(guildID string, messageID string, page int) (*discordgo.InteractionResponseData, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cachedMsg, err := m.getCachedMessage(ctx, messageID)
+	if err != nil || cachedMsg.Ignored || cachedMsg.ContentOmitted {
+		return nil, false
+	}
+	channel, err := m.discord.State.Channel(cachedMsg.ChannelID)
+	if err != nil || channel.GuildID != guildID {
+		return nil, false
+	}
+
+	revisions := append(cachedMsg.Revisions, cachedMsg.currentRevision(messageID))
+	redactionRuleSet := m.getRedactionRuleSet(guildID)
+	for i := range revisions {
+		revisions[i].Content, _ = redactionRuleSet.redact(revisions[i].Content)
+	}
+	pageCount := (len(revisions) + historyPageSize - 1) / historyPageSize
+	if page >= pageCount {
+		page = pageCount - 1
+	}
+
+	var fields []*discordgo.MessageEmbedField
+	end := (page + 1) * historyPageSize
+	if end > len(revisions) {
+		end = len(revisions)
+	}
+	for i := page * historyPageSize; i < end; i++ {
+		name := "Revision " + strconv.Itoa(cachedMsg.DroppedRevisions+i+1)
+		if i == len(revisions)-1 {
+			name += " (current)"
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: formatRevision(revisions[i]),
+		})
+	}
+
+	description := "Message by **" + cachedMsg.AuthorFullName + "** in <#" + cachedMsg.ChannelID + ">, [jump to message](https://discord.com/channels/" + guildID + "/" + cachedMsg.ChannelID + "/" + messageID + ")"
+	if len(cachedMsg.Revisions) == 0 {
+		description += "\nThe message was not edited since it was cached."
+	}
+	if cachedMsg.DroppedRevisions > 0 {
+		description += "\nThe " + strconv.Itoa(cachedMsg.DroppedRevisions) + " oldest revisions are no longer cached."
+	}
+
+	return &discordgo.InteractionResponseData{
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Type:        discordgo.EmbedTypeRich,
+				Title:       "Edit History",
+				Description: description,
+				Fields:      fields,
+				Color:       util.EmbedColorInfo,
+				Timestamp:   time.Now().Format(time.RFC3339),
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: "Page " + strconv.Itoa(page+1) + "/" + strconv.Itoa(pageCount) + " · " + strconv.Itoa(len(revisions)) + " revisions · gowlbot " + util.GetVersionString(),
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Previous",
+						Style:    discordgo.SecondaryButton,
+						CustomID: historyCustomIDPrefix + messageID + ":" + strconv.Itoa(page-1),
+						Disabled: page == 0,
+					},
+					discordgo.Button{
+						Label:    "Next",
+						Style:    discordgo.SecondaryButton,
+						CustomID: historyCustomIDPrefix + messageID + ":" + strconv.Itoa(page+1),
+						Disabled: page+1 >= pageCount,
+					},
+				},
+			},
+		},
+	}, true
+}
+
+func formatRevision(revision CachedRevision) string {
+	value := formatDiscordTimestamp(revision.Timestamp) + "\n"
+	if len(revision.Attachments) > 0 {
+		value += "Attachments: " + strings.Join(revision.Attachments, ", ") + "\n"
+	}
+	content := revision.Content
+	if len(content) == 0 {
+		content = "*No content*"
+	}
+	value += content
+	if utf8.RuneCountInString(value) > embedFieldValueMaxLength {
+		value = substringUTF8(value, 0, embedFieldValueMaxLength-1) + "…"
+	}
+	return value
+}
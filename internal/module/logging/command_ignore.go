@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func (m *Module) handleLoggingIgnoreCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if _, ok := optionMap[CommandOptionIgnoreAddCmd]; ok {
+		m.handleLoggingIgnoreAddCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionIgnoreRemoveCmd]; ok {
+		m.handleLoggingIgnoreRemoveCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionIgnoreListCmd]; ok {
+		m.handleLoggingIgnoreListCommand(interaction)
+	}
+}
+
+func (m *Module) handleLoggingIgnoreAddCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error adding logging ignore rule", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	rule := LoggingIgnoreRule{GuildID: interaction.GuildID}
+
+	if _, ok := optionMap[CommandOptionLoggingType]; ok {
+		logType, details := parseLogTypeOption(optionMap)
+		if len(details) > 0 {
+			handleError(details)
+			return
+		}
+		rule.LogType = logType
+	}
+
+	var targets []LoggingIgnoreRule
+	if channelOption, ok := optionMap[CommandOptionChannel]; ok {
+		channelID, details := m.parseChannelOption(interaction.GuildID, channelOption)
+		if len(details) > 0 {
+			handleError(details)
+			return
+		}
+		targetType := IgnoreTargetChannel
+		if channel := channelOption.ChannelValue(m.discord); channel.Type == discordgo.ChannelTypeGuildCategory {
+			targetType = IgnoreTargetCategory
+		}
+		targets = append(targets, LoggingIgnoreRule{TargetType: targetType, TargetID: channelID})
+	}
+	if roleOption, ok := optionMap[CommandOptionRole]; ok {
+		targets = append(targets, LoggingIgnoreRule{TargetType: IgnoreTargetRole, TargetID: roleOption.Value.(string)})
+	}
+	if userOption, ok := optionMap[CommandOptionUser]; ok {
+		targets = append(targets, LoggingIgnoreRule{TargetType: IgnoreTargetUser, TargetID: userOption.Value.(string)})
+	}
+	if botsOption, ok := optionMap[CommandOptionBots]; ok && botsOption.BoolValue() {
+		targets = append(targets, LoggingIgnoreRule{TargetType: IgnoreTargetBots})
+	}
+	if webhooksOption, ok := optionMap[CommandOptionWebhooks]; ok && webhooksOption.BoolValue() {
+		targets = append(targets, LoggingIgnoreRule{TargetType: IgnoreTargetWebhooks})
+	}
+
+	if len(targets) != 1 {
+		handleError("Please specify exactly one channel, category, role, user, bots or webhooks to ignore.")
+		return
+	}
+	rule.TargetType = targets[0].TargetType
+	rule.TargetID = targets[0].TargetID
+
+	var count int64
+	dbResult := m.db.Model(&LoggingIgnoreRule{}).Where("guild_id = ? AND log_type = ? AND target_type = ? AND target_id = ?", rule.GuildID, rule.LogType, rule.TargetType, rule.TargetID).Count(&count)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching logging ignore rules from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if count > 0 {
+		handleError("This ignore rule already exists.")
+		return
+	}
+
+	dbResult = m.db.Create(&rule)
+	m.ignoreRules.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error creating logging ignore rule in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Added ignore rule with ID " + strconv.FormatUint(uint64(rule.ID), 10) + ": " + formatIgnoreRule(rule),
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingIgnoreRemoveCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error removing logging ignore rule", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	idOption, ok := optionMap[CommandOptionIgnoreID]
+	if !ok || idOption.Type != discordgo.ApplicationCommandOptionInteger {
+		handleError("Ignore rule ID missing or invalid.")
+		return
+	}
+	ruleID := idOption.IntValue()
+
+	dbResult := m.db.Where("guild_id = ? AND id = ?", interaction.GuildID, ruleID).Delete(&LoggingIgnoreRule{})
+	m.ignoreRules.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error deleting logging ignore rule from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if dbResult.RowsAffected == 0 {
+		handleError("The given ignore rule ID was not found on your guild.")
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Ignore rule with ID " + strconv.FormatInt(ruleID, 10) + " was removed.",
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingIgnoreListCommand(interaction *discordgo.Interaction) {
+	var rules []LoggingIgnoreRule
+
+	result := m.db.Where(&LoggingIgnoreRule{GuildID: interaction.GuildID}).Order("id").Find(&rules)
+
+	if result.Error != nil {
+		m.logger.Error("Error fetching logging ignore rules", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(result.Error))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "There was an error fetching the ignore rules for this server.",
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+		}
+		return
+	}
+
+	var lines []string
+	for _, rule := range rules {
+		lines = append(lines, strconv.FormatUint(uint64(rule.ID), 10)+": "+formatIgnoreRule(rule))
+	}
+	description := "No ignore rules configured"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	if utf8.RuneCountInString(description) > embedDescriptionMaxLength {
+		description = substringUTF8(description, 0, embedDescriptionMaxLength-1) + "…"
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Type:        discordgo.EmbedTypeRich,
+					Title:       "Logging Ignore Rules",
+					Description: description,
+					Color:       util.EmbedColorInfo,
+					Timestamp:   time.Now().Format(time.RFC3339),
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: "gowlbot " + util.GetVersionString(),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func formatIgnoreRule(rule LoggingIgnoreRule) string {
+	logTypes := "all log types"
+	if len(rule.LogType) > 0 {
+		logTypes = rule.LogType.ToReadableString()
+	}
+	return rule.TargetType.formatTarget(rule.TargetID) + " (" + logTypes + ")"
+}
@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/templating"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const samplePreviousContent = "Has anyone tried the new update yet? I spent the whole evening on it and I have some thoughts. "
+
+func (m *Module) handleLoggingPreviewCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(response *discordgo.InteractionResponseData) {
+		response.Flags = discordgo.MessageFlagsEphemeral
+		// previews must not ping the sample users and roles
+		response.AllowedMentions = &discordgo.MessageAllowedMentions{}
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: response,
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	logType, details := parseLogTypeOption(optionMap)
+	if len(details) > 0 {
+		respond(&discordgo.InteractionResponseData{Content: details})
+		return
+	}
+
+	// preview the configured destination unless a draft is given
+	format := defaultLoggingFormats[logType]
+	outputMode := OutputModeText
+	destination := GuildLoggingDestination{}
+	dbResult := m.db.Where(&GuildLoggingDestination{GuildID: interaction.GuildID, LogType: logType}).Order("id").Limit(1).Find(&destination)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching logging destinations from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+	} else if dbResult.RowsAffected > 0 {
+		format = destination.Format
+		outputMode = destination.OutputMode
+	}
+
+	if formatOption, ok := optionMap[CommandOptionFormat]; ok {
+		format = formatOption.StringValue()
+	}
+	if outputModeOption, ok := optionMap[CommandOptionOutputMode]; ok {
+		outputMode, details = parseOutputModeOption(outputModeOption)
+		if len(details) > 0 {
+			respond(&discordgo.InteractionResponseData{Content: details})
+			return
+		}
+	}
+
+	tmpl, err := templating.Parse(format)
+	if err == nil {
+		err = tmpl.Validate(getLogTypePlaceholders(logType))
+	}
+	if err != nil {
+		respond(&discordgo.InteractionResponseData{Content: "The format is invalid: " + err.Error() + "\nAvailable placeholders: " + formatPlaceholderList(logType)})
+		return
+	}
+
+	data := m.buildSampleLogData(interaction, logType)
+
+	if outputMode == OutputModeEmbed {
+		respond(&discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{buildLogEmbed(logType, tmpl, data)}})
+		return
+	}
+
+	messages := renderTextLog(tmpl, data)
+	respond(&discordgo.InteractionResponseData{Content: messages[0]})
+	for i, content := range messages[1:] {
+		_, err = m.discord.FollowupMessageCreate(interaction, false, &discordgo.WebhookParams{
+			Content:         content,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			m.logger.Error("Error sending preview follow-up message", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Int("part", i+1), zap.Error(err))
+			return
+		}
+	}
+}
+
+// buildSampleLogData returns realistic values for all placeholders of the log type, using the invoking member as
+// user. Content is long enough to show how it is split across messages or embed fields.
+func (m *Module) buildSampleLogData(interaction *discordgo.Interaction, logType LogType) map[string]string {
+	user := interaction.User
+	if interaction.Member != nil {
+		user = interaction.Member.User
+	}
+
+	now := time.Now()
+	previousContent := strings.Repeat(samplePreviousContent, 14)
+	newContent := strings.Replace(previousContent, "whole evening", "entire weekend", 1) + "Edit: fixed a typo."
+	contentDiff := diffWords(previousContent, newContent)
+	attachments := formatAttachmentList([]CachedAttachment{
+		{Filename: "screenshot.png", Size: 245_760, ContentType: "image/png", URL: "https://cdn.discordapp.com/attachments/0/0/screenshot.png"},
+	})
+
+	memberCount := "1234"
+	if guild, err := m.discord.State.Guild(interaction.GuildID); err == nil && guild.MemberCount > 0 {
+		memberCount = strconv.Itoa(guild.MemberCount)
+	}
+
+	samples := map[string]string{
+		"time":                strconv.FormatInt(now.Unix(), 10),
+		"channel_id":          interaction.ChannelID,
+		"channel_name":        m.getChannelName(interaction.ChannelID),
+		"channel_type":        formatChannelType(discordgo.ChannelTypeGuildText),
+		"parent_id":           "",
+		"parent_name":         "Text Channels",
+		"old_channel_id":      interaction.ChannelID,
+		"author_id":           user.ID,
+		"author_full_name":    user.String(),
+		"member_id":           user.ID,
+		"member_full_name":    user.String(),
+		"moderator_id":        user.ID,
+		"moderator_full_name": user.String(),
+		"reason":              "Spamming in multiple channels",
+		"previous_content":    previousContent,
+		"new_content":         newContent,
+		"content_diff":        renderDiffANSI(contentDiff),
+		"content_diff_plain":  renderDiffPlain(contentDiff),
+		"attachments":         attachments,
+		"reply_to_author":     "Wumpus#0001",
+		"reply_to_author_id":  interaction.GuildID,
+		"reply_to_message_id": interaction.ID,
+		"mentions":            "<@" + user.ID + ">",
+		"stickers":            "Wumpus Wave",
+		"embeds":              "Patch Notes\nAll changes of the new update at a glance.",
+		"message_created":     strconv.FormatInt(now.Add(-time.Minute*42).Unix(), 10),
+		"message_age":         formatDuration(time.Minute * 42),
+		"removed_attachments": attachments,
+		"message_count":       "42",
+		"cached_count":        "40",
+		"authors":             user.String() + " (30), " + "Wumpus#0001 (10)",
+		"guild_member_count":  memberCount,
+		"invite_code":         "gowlbot",
+		"invite_uses":         "7",
+		"inviter_id":          user.ID,
+		"inviter_full_name":   user.String(),
+		"max_uses":            "Unlimited",
+		"max_age":             formatDuration(time.Hour * 24),
+		"temporary":           "false",
+		"old_roles":           "Member",
+		"new_roles":           "Member,Moderator",
+		"role_changes":        "+Moderator",
+		"role_id":             interaction.GuildID,
+		"role_name":           "Moderator",
+		"old_role_name":       "Mod",
+		"role_color":          formatRoleColor(0x5865F2),
+		"permissions":         formatPermissionList(discordgo.PermissionKickMembers | discordgo.PermissionBanMembers),
+		"permission_changes":  formatPermissionDifferences(discordgo.PermissionKickMembers, discordgo.PermissionKickMembers|discordgo.PermissionBanMembers),
+		"changes":             "Name: `general` → `general-chat`\nSlowmode: " + formatSlowmode(0) + " → " + formatSlowmode(10),
+		"old_nickname":        "Owl",
+		"new_nickname":        "Night Owl",
+		"old_username":        "owl#0001",
+		"new_username":        user.String(),
+		"new_avatar_url":      user.AvatarURL("256"),
+		"session_duration":    formatDuration(time.Minute * 83),
+		"timeout_until":       formatDiscordTimestamp(now.Add(time.Hour)),
+		"timeout_end_reason":  "Removed",
+		"ghost_ping_action":   "deleted",
+		"redactions":          "1",
+		"kicked_by":           "",
+	}
+
+	data := make(map[string]string)
+	for _, placeholder := range getLogTypePlaceholders(logType) {
+		data[placeholder] = samples[placeholder]
+	}
+	return data
+}
@@ -0,0 +1,198 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func (m *Module) handleLoggingRedactionCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	if _, ok := optionMap[CommandOptionRedactionAddCmd]; ok {
+		m.handleLoggingRedactionAddCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionRedactionRemoveCmd]; ok {
+		m.handleLoggingRedactionRemoveCommand(interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionRedactionListCmd]; ok {
+		m.handleLoggingRedactionListCommand(interaction)
+	}
+}
+
+func (m *Module) handleLoggingRedactionAddCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error adding redaction rule", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	detectorOption, ok := optionMap[CommandOptionRedactionDetector]
+	if !ok || detectorOption.Type != discordgo.ApplicationCommandOptionString {
+		handleError("Detector missing or invalid.")
+		return
+	}
+	detector, ok := ParseRedactionDetector(detectorOption.StringValue())
+	if !ok {
+		handleError("Unknown detector.")
+		return
+	}
+
+	rule := RedactionRule{GuildID: interaction.GuildID, Detector: detector}
+	patternOption, hasPattern := optionMap[CommandOptionRedactionPattern]
+	if detector == RedactionDetectorCustom {
+		if !hasPattern {
+			handleError("Custom rules need a pattern.")
+			return
+		}
+		rule.Pattern = patternOption.StringValue()
+		if _, err := compileRedactionPattern(rule.Pattern); err != nil {
+			handleError("The pattern is invalid: " + err.Error())
+			return
+		}
+	} else if hasPattern {
+		handleError("Patterns can only be used with custom rules.")
+		return
+	}
+
+	var count int64
+	dbResult := m.db.Model(&RedactionRule{}).Where("guild_id = ? AND detector = ? AND pattern = ?", rule.GuildID, rule.Detector, rule.Pattern).Count(&count)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching redaction rules from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if count > 0 {
+		handleError("This redaction rule already exists.")
+		return
+	}
+
+	dbResult = m.db.Create(&rule)
+	m.redactionRuleSets.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error creating redaction rule in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Added redaction rule with ID " + strconv.FormatUint(uint64(rule.ID), 10) + ": " + formatRedactionRule(rule),
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingRedactionRemoveCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		m.logger.Error("Error removing redaction rule", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	idOption, ok := optionMap[CommandOptionRedactionID]
+	if !ok || idOption.Type != discordgo.ApplicationCommandOptionInteger {
+		handleError("Redaction rule ID missing or invalid.")
+		return
+	}
+	ruleID := idOption.IntValue()
+
+	dbResult := m.db.Where("guild_id = ? AND id = ?", interaction.GuildID, ruleID).Delete(&RedactionRule{})
+	m.redactionRuleSets.Delete(interaction.GuildID)
+	if dbResult.Error != nil {
+		m.logger.Error("Error deleting redaction rule from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+	if dbResult.RowsAffected == 0 {
+		handleError("The given redaction rule ID was not found on your guild.")
+		return
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Removed redaction rule with ID " + strconv.FormatInt(ruleID, 10) + ".",
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingRedactionListCommand(interaction *discordgo.Interaction) {
+	var rules []RedactionRule
+
+	result := m.db.Where(&RedactionRule{GuildID: interaction.GuildID}).Order("id").Find(&rules)
+
+	if result.Error != nil {
+		m.logger.Error("Error fetching redaction rules", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(result.Error))
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "There was an error fetching the redaction rules for this server.",
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+		}
+		return
+	}
+
+	var lines []string
+	for _, rule := range rules {
+		lines = append(lines, strconv.FormatUint(uint64(rule.ID), 10)+": "+formatRedactionRule(rule))
+	}
+	description := "No redaction rules configured"
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+	if utf8.RuneCountInString(description) > embedDescriptionMaxLength {
+		description = substringUTF8(description, 0, embedDescriptionMaxLength-1) + "…"
+	}
+
+	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Type:        discordgo.EmbedTypeRich,
+					Title:       "Logging Redaction Rules",
+					Description: description,
+					Color:       util.EmbedColorInfo,
+					Timestamp:   time.Now().Format(time.RFC3339),
+					Footer: &discordgo.MessageEmbedFooter{
+						Text: "gowlbot " + util.GetVersionString(),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func formatRedactionRule(rule RedactionRule) string {
+	if rule.Detector == RedactionDetectorCustom {
+		return rule.Detector.ToReadableString() + " " + escapeDiscordString(rule.Pattern)
+	}
+	return rule.Detector.ToReadableString()
+}
@@ -0,0 +1,218 @@
+package logging
+
+import (
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	searchPageSize       = 10
+	searchCustomIDPrefix = "logging-search:"
+	searchTTL            = time.Minute * 30
+)
+
+func searchCacheKey(searchID string) string {
+	return "logging-search:" + searchID
+}
+
+func (m *Module) handleLoggingSearchCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	handleError := func(details string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: details,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	query := LogRecordQuery{GuildID: interaction.GuildID}
+
+	if userOption, ok := optionMap[CommandOptionUser]; ok {
+		query.UserID = userOption.Value.(string)
+	}
+	if channelOption, ok := optionMap[CommandOptionChannel]; ok {
+		// archived records can belong to deleted channels, so the raw id is used
+		query.ChannelID = channelOption.Value.(string)
+	}
+	if _, ok := optionMap[CommandOptionLoggingType]; ok {
+		logType, details := parseLogTypeOption(optionMap)
+		if len(details) > 0 {
+			handleError(details)
+			return
+		}
+		query.LogTypes = []LogType{logType}
+	}
+
+	now := time.Now()
+	var err error
+	if afterOption, ok := optionMap[CommandOptionAfter]; ok {
+		query.After, err = ParseTimeFilter(afterOption.StringValue(), now)
+		if err != nil {
+			handleError("Invalid `after` value: " + err.Error())
+			return
+		}
+	}
+	if beforeOption, ok := optionMap[CommandOptionBefore]; ok {
+		query.Before, err = ParseTimeFilter(beforeOption.StringValue(), now)
+		if err != nil {
+			handleError("Invalid `before` value: " + err.Error())
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// without redis the first page is still shown, the page buttons report the search as expired
+	err = m.cache.Set(ctx, searchCacheKey(interaction.ID), &query, searchTTL).Err()
+	if err != nil {
+		m.logger.Warn("Error storing log search in cache", zap.String("guild", interaction.GuildID), zap.Error(err))
+	}
+
+	response, err := m.buildSearchResponse(query, interaction.ID, 0)
+	if err != nil {
+		m.logger.Error("Error searching log records", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	err = m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: response,
+	})
+	if err != nil {
+		m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	}
+}
+
+func (m *Module) handleLoggingSearchPageButton(interaction *discordgo.Interaction, customID string) {
+	respond := func(response *discordgo.InteractionResponseData) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: response,
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	searchID, pageStr, ok := strings.Cut(strings.TrimPrefix(customID, searchCustomIDPrefix), ":")
+	page, err := strconv.Atoi(pageStr)
+	if !ok || err != nil || page < 0 {
+		m.logger.Warn("Invalid log search button", zap.String("guild", interaction.GuildID), zap.String("customID", customID))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	query := LogRecordQuery{}
+	err = m.cache.Get(ctx, searchCacheKey(searchID)).Scan(&query)
+	if err != nil || query.GuildID != interaction.GuildID {
+		respond(&discordgo.InteractionResponseData{
+			Content:    "This search has expired, please run `/logging search` again.",
+			Embeds:     []*discordgo.MessageEmbed{},
+			Components: []discordgo.MessageComponent{},
+		})
+		return
+	}
+
+	response, err := m.buildSearchResponse(query, searchID, page)
+	if err != nil {
+		m.logger.Error("Error searching log records", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		respond(&discordgo.InteractionResponseData{Content: "An internal error occurred. [" + interaction.ID + "]"})
+		return
+	}
+	respond(response)
+}
+
+func (m *Module) buildSearchResponse(query LogRecordQuery, searchID string, page int) (*discordgo.InteractionResponseData, error) {
+	records, total, err := m.searchLogRecords(query, page*searchPageSize, searchPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pageCount := int((total + searchPageSize - 1) / searchPageSize)
+	if pageCount == 0 {
+		pageCount = 1
+	}
+
+	var lines []string
+	for _, record := range records {
+		lines = append(lines, "`#"+strconv.FormatUint(uint64(record.ID), 10)+"` "+formatLogRecordSummary(record))
+	}
+	description := "No log records found."
+	if len(lines) > 0 {
+		description = strings.Join(lines, "\n")
+	}
+
+	var filters []string
+	if len(query.UserID) > 0 {
+		filters = append(filters, "User: <@"+query.UserID+">")
+	}
+	for _, logType := range query.LogTypes {
+		filters = append(filters, "Type: "+logType.ToReadableString())
+	}
+	if len(query.ChannelID) > 0 {
+		filters = append(filters, "Channel: <#"+query.ChannelID+">")
+	}
+	if !query.After.IsZero() {
+		filters = append(filters, "After: "+formatDiscordTimestamp(query.After))
+	}
+	if !query.Before.IsZero() {
+		filters = append(filters, "Before: "+formatDiscordTimestamp(query.Before))
+	}
+	if len(filters) == 0 {
+		filters = append(filters, "None")
+	}
+
+	return &discordgo.InteractionResponseData{
+		Flags:           discordgo.MessageFlagsEphemeral,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Type:        discordgo.EmbedTypeRich,
+				Title:       "Log Search",
+				Description: description,
+				Fields: []*discordgo.MessageEmbedField{
+					{
+						Name:  "Filters",
+						Value: strings.Join(filters, "\n"),
+					},
+				},
+				Color:     util.EmbedColorInfo,
+				Timestamp: time.Now().Format(time.RFC3339),
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: "Page " + strconv.Itoa(page+1) + "/" + strconv.Itoa(pageCount) + " · " + strconv.FormatInt(total, 10) + " results · gowlbot " + util.GetVersionString(),
+				},
+			},
+		},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Previous",
+						Style:    discordgo.SecondaryButton,
+						CustomID: searchCustomIDPrefix + searchID + ":" + strconv.Itoa(page-1),
+						Disabled: page == 0,
+					},
+					discordgo.Button{
+						Label:    "Next",
+						Style:    discordgo.SecondaryButton,
+						CustomID: searchCustomIDPrefix + searchID + ":" + strconv.Itoa(page+1),
+						Disabled: page+1 >= pageCount,
+					},
+				},
+			},
+		},
+	}, nil
+}
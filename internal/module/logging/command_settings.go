@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+func (m *Module) handleLoggingRetentionCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	daysOption, ok := optionMap[CommandOptionRetentionDays]
+	if !ok {
+		respond("Log records are kept for " + formatRetention(m.getArchiveRetentionDays(interaction.GuildID)) + ".")
+		return
+	}
+
+	days := int(daysOption.IntValue())
+	if days < 0 || days > maxArchiveRetentionDays {
+		respond("The retention has to be between 0 and " + strconv.Itoa(maxArchiveRetentionDays) + " days.")
+		return
+	}
+
+	err := m.updateGuildLoggingConfig(interaction.GuildID, func(guildConfig *GuildLoggingConfig) {
+		guildConfig.ArchiveRetentionDays = &days
+	})
+	if err != nil {
+		m.logger.Error("Error updating guild logging config in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	respond("Log records will now be kept for " + formatRetention(days) + ". Older records are deleted within the next hour.")
+}
+
+func formatRetention(days int) string {
+	if days == 0 {
+		return "0 days (archive disabled)"
+	}
+	if days == 1 {
+		return "1 day"
+	}
+	return strconv.Itoa(days) + " days"
+}
+
+func (m *Module) handleLoggingWarmupCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	messagesOption, ok := optionMap[CommandOptionWarmupMessages]
+	if !ok {
+		respond("On startup the last " + strconv.Itoa(m.getCacheWarmupMessages(interaction.GuildID)) + " messages of every channel are cached.")
+		return
+	}
+
+	messages := int(messagesOption.IntValue())
+	if messages < 0 || messages > maxCacheWarmupMessages {
+		respond("The number of messages has to be between 0 and " + strconv.Itoa(maxCacheWarmupMessages) + ".")
+		return
+	}
+
+	err := m.updateGuildLoggingConfig(interaction.GuildID, func(guildConfig *GuildLoggingConfig) {
+		guildConfig.CacheWarmupMessages = &messages
+	})
+	if err != nil {
+		m.logger.Error("Error updating guild logging config in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	if messages == 0 {
+		respond("The message cache will no longer be warmed up on startup.")
+		return
+	}
+	respond("On startup the last " + strconv.Itoa(messages) + " messages of every channel will be cached.")
+}
+
+func (m *Module) handleLoggingGhostPingCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
+	respond := func(content string) {
+		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+			},
+		})
+		if err != nil {
+			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		}
+	}
+
+	windowOption, hasWindow := optionMap[CommandOptionGhostPingWindow]
+	noticeOption, hasNotice := optionMap[CommandOptionGhostPingNotice]
+	if !hasWindow && !hasNotice {
+		respond(formatGhostPingSettings(m.getGuildLoggingConfig(interaction.GuildID)))
+		return
+	}
+
+	var window int
+	if hasWindow {
+		window = int(windowOption.IntValue())
+		if window < 0 || window > maxGhostPingWindowSeconds {
+			respond("The window has to be between 0 and " + strconv.Itoa(maxGhostPingWindowSeconds) + " seconds.")
+			return
+		}
+	}
+
+	var updatedConfig GuildLoggingConfig
+	err := m.updateGuildLoggingConfig(interaction.GuildID, func(guildConfig *GuildLoggingConfig) {
+		if hasWindow {
+			guildConfig.GhostPingWindowSeconds = &window
+		}
+		if hasNotice {
+			guildConfig.GhostPingNotice = noticeOption.BoolValue()
+		}
+		updatedConfig = *guildConfig
+	})
+	if err != nil {
+		m.logger.Error("Error updating guild logging config in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		respond("An internal error occurred. [" + interaction.ID + "]")
+		return
+	}
+
+	respond("Ghost ping settings updated. " + formatGhostPingSettings(updatedConfig))
+}
+
+func formatGhostPingSettings(guildConfig GuildLoggingConfig) string {
+	notice := "disabled"
+	if guildConfig.GhostPingNotice {
+		notice = "enabled"
+	}
+	window := int(getGhostPingWindow(guildConfig).Seconds())
+	return "Mentions removed within " + strconv.Itoa(window) + " seconds are reported as ghost pings, public notices are " + notice + "."
+}
@@ -4,14 +4,16 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/yannismate/gowlbot/internal/util"
 	"go.uber.org/zap"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 func (m *Module) handleLoggingStatusCommand(interaction *discordgo.Interaction) {
 
-	var settings []GuildLoggingSetting
+	var destinations []GuildLoggingDestination
 
-	result := m.db.Where(&GuildLoggingSetting{GuildID: interaction.GuildID}).Find(&settings)
+	result := m.db.Where(&GuildLoggingDestination{GuildID: interaction.GuildID}).Order("id").Find(&destinations)
 
 	if result.Error != nil {
 		m.logger.Error("Error fetching guild logging settings", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(result.Error))
@@ -27,22 +29,37 @@ func (m *Module) handleLoggingStatusCommand(interaction *discordgo.Interaction)
 		return
 	}
 
-	settingsMap := make(map[LogType]GuildLoggingSetting)
-	for _, setting := range settings {
-		settingsMap[setting.LogType] = setting
+	destinationsMap := make(map[LogType][]GuildLoggingDestination)
+	for _, destination := range destinations {
+		destinationsMap[destination.LogType] = append(destinationsMap[destination.LogType], destination)
 	}
 
 	getEnabledString := func(logType LogType) string {
-		setting, ok := settingsMap[logType]
-		if ok && setting.Enabled {
-			if len(setting.LoggingChannelID) > 0 {
-				return "Enabled (<#" + setting.LoggingChannelID + ">)"
-			} else {
-				return "Enabled (Channel not configured!)"
+		logTypeDestinations, ok := destinationsMap[logType]
+		if !ok {
+			if fallbackLogType, ok := logTypeFallbacks[logType]; ok && len(destinationsMap[fallbackLogType]) > 0 {
+				return "Same as " + fallbackLogType.ToReadableString()
 			}
-		} else {
 			return "Disabled"
 		}
+		return strings.ReplaceAll(formatDestinationList(logTypeDestinations), "\n", ", ")
+	}
+
+	// one field per category, discord allows at most 25 fields per embed
+	var embedFields []*discordgo.MessageEmbedField
+	for _, category := range logTypeCategories {
+		var lines []string
+		for _, logType := range category.LogTypes {
+			lines = append(lines, "**"+logType.ToReadableString()+"**: "+getEnabledString(logType))
+		}
+		value := strings.Join(lines, "\n")
+		if utf8.RuneCountInString(value) > embedFieldValueMaxLength {
+			value = substringUTF8(value, 0, embedFieldValueMaxLength-1) + "…"
+		}
+		embedFields = append(embedFields, &discordgo.MessageEmbedField{
+			Name:  category.Name,
+			Value: value,
+		})
 	}
 
 	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
@@ -50,38 +67,9 @@ func (m *Module) handleLoggingStatusCommand(interaction *discordgo.Interaction)
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{
 				{
-					Type:  discordgo.EmbedTypeRich,
-					Title: "Current Logging Status",
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:  MessageEdit.ToReadableString(),
-							Value: getEnabledString(MessageEdit),
-						},
-						{
-							Name:  MessageDelete.ToReadableString(),
-							Value: getEnabledString(MessageDelete),
-						},
-						{
-							Name:  MemberJoin.ToReadableString(),
-							Value: getEnabledString(MemberJoin),
-						},
-						{
-							Name:  MemberLeave.ToReadableString(),
-							Value: getEnabledString(MemberLeave),
-						},
-						{
-							Name:  MemberRoleChange.ToReadableString(),
-							Value: getEnabledString(MemberRoleChange),
-						},
-						{
-							Name:  GuildBanAdd.ToReadableString(),
-							Value: getEnabledString(GuildBanAdd),
-						},
-						{
-							Name:  GuildBanRemove.ToReadableString(),
-							Value: getEnabledString(GuildBanRemove),
-						},
-					},
+					Type:      discordgo.EmbedTypeRich,
+					Title:     "Current Logging Status",
+					Fields:    embedFields,
 					Color:     util.EmbedColorInfo,
 					Timestamp: time.Now().Format(time.RFC3339),
 					Footer: &discordgo.MessageEmbedFooter{
@@ -1,35 +1,52 @@
 package logging
 
 import (
-	"errors"
 	"github.com/bwmarrin/discordgo"
 	"github.com/yannismate/gowlbot/internal/util"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
-	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	defaultLoggingFormats = map[LogType]string{
-		MessageEdit:      "✏ <t:{time}> <#{channel_id}> **{author_full_name}** edited their message. Previous content: {previous_content}",
-		MessageDelete:    "🗑 <t:{time}> <#{channel_id}> Message by **{author_full_name}** was deleted. Content: {previous_content}",
-		MemberJoin:       "📥 <t:{time}> <@{member_id}> ({member_full_name}) joined the server. Total members: {guild_member_count}",
-		MemberLeave:      "📤 <t:{time}> <@{member_id}> ({member_full_name}) left the server or got kicked. Total members: {guild_member_count}",
-		MemberRoleChange: "👥 <t:{time}> **{member_full_name}**'s roles changed: `{role_changes}`",
-		GuildBanAdd:      "⛔️ <t:{time}> <@{member_id}> was banned.",
-		GuildBanRemove:   "✅ <t:{time}> <@{member_id}> was unbanned.",
+		MessageEdit:          "✏ <t:{time}> <#{channel_id}> **{author_full_name}** edited their message. Changes: {content_diff}",
+		MessageDelete:        "🗑 <t:{time}> <#{channel_id}> Message by **{author_full_name}**{if reply_to_author} replying to **{reply_to_author}**{end} was deleted. Content: {previous_content} Attachments: {attachments}",
+		MessageBulkDelete:    "🧹 <t:{time}> <#{channel_id}> {message_count} messages were bulk deleted by **{moderator_full_name}** ({cached_count} found in cache). Authors: {authors}",
+		MemberJoin:           "📥 <t:{time}> <@{member_id}> ({member_full_name}) joined the server via invite `{invite_code}` by **{inviter_full_name}**. Total members: {guild_member_count}",
+		MemberLeave:          "📤 <t:{time}> <@{member_id}> ({member_full_name}) {if kicked_by}was kicked by **{kicked_by}**{else}left the server{end}. Total members: {guild_member_count}",
+		MemberKick:           "👢 <t:{time}> <@{member_id}> ({member_full_name}) was kicked by **{moderator_full_name}**. Reason: {reason}. Total members: {guild_member_count}",
+		MemberRoleChange:     "👥 <t:{time}> **{member_full_name}**'s roles changed by **{moderator_full_name}**: `{role_changes}`",
+		GuildBanAdd:          "⛔️ <t:{time}> <@{member_id}> was banned by **{moderator_full_name}**. Reason: {reason}",
+		GuildBanRemove:       "✅ <t:{time}> <@{member_id}> was unbanned by **{moderator_full_name}**.",
+		ChannelCreate:        "🆕 <t:{time}> {channel_type} <#{channel_id}> (**{channel_name}**) was created in **{parent_name}** by **{moderator_full_name}**.",
+		ChannelDelete:        "🚮 <t:{time}> {channel_type} **{channel_name}** was deleted from **{parent_name}** by **{moderator_full_name}**.",
+		ChannelUpdate:        "🔧 <t:{time}> {channel_type} <#{channel_id}> was updated by **{moderator_full_name}**:\n{changes}",
+		RoleCreate:           "🆕 <t:{time}> Role **{role_name}** was created by **{moderator_full_name}**. Permissions: {permissions}",
+		RoleDelete:           "🚮 <t:{time}> Role **{role_name}** was deleted by **{moderator_full_name}**.",
+		RoleUpdate:           "🔧 <t:{time}> Role **{role_name}** was updated by **{moderator_full_name}**:\n{changes}",
+		MemberNicknameChange: "🏷 <t:{time}> **{member_full_name}**'s nickname was changed from `{old_nickname}` to `{new_nickname}` by **{moderator_full_name}**.",
+		UserProfileChange:    "🪪 <t:{time}> <@{member_id}> ({old_username}) updated their profile:\n{changes}",
+		VoiceJoin:            "🔊 <t:{time}> **{member_full_name}** joined <#{channel_id}>.",
+		VoiceLeave:           "🔇 <t:{time}> **{member_full_name}** left <#{channel_id}> after {session_duration}.",
+		VoiceMove:            "🔀 <t:{time}> **{member_full_name}** moved from <#{old_channel_id}> to <#{channel_id}>.",
+		VoiceStateChange:     "🎙 <t:{time}> **{member_full_name}** in <#{channel_id}>: {changes}",
+		InviteCreate:         "✉️ <t:{time}> **{inviter_full_name}** created invite `{invite_code}` for <#{channel_id}>. Max uses: {max_uses}, expires after: {max_age}",
+		InviteDelete:         "📪 <t:{time}> Invite `{invite_code}` by **{inviter_full_name}** was deleted after {invite_uses} uses.",
+		MemberTimeoutAdd:     "🔇 <t:{time}> <@{member_id}> ({member_full_name}) was timed out until {timeout_until} by **{moderator_full_name}**. Reason: {reason}",
+		MemberTimeoutRemove:  "🔈 <t:{time}> Timeout of <@{member_id}> ({member_full_name}) ended: {timeout_end_reason}. Moderator: **{moderator_full_name}**",
+		GhostPing:            "👻 <t:{time}> <#{channel_id}> **{author_full_name}** ghost pinged {mentions}, the message was {ghost_ping_action} after {message_age}. Content: {previous_content}",
 	}
 )
 
 func (m *Module) handleLoggingUpdateCommand(interaction *discordgo.Interaction, optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) {
-
-	handleParseError := func(details string) {
+	handleError := func(details string) {
 		m.logger.Error("Error parsing logging update", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.String("details", details))
 		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "There was an error parsing your command inputs.",
+				Content: details,
 			},
 		})
 		if err != nil {
@@ -37,122 +54,146 @@ func (m *Module) handleLoggingUpdateCommand(interaction *discordgo.Interaction,
 		}
 	}
 
-	logTypeOption, ok := optionMap[CommandOptionLoggingType]
-	if !ok || logTypeOption.Type != discordgo.ApplicationCommandOptionString {
-		handleParseError("Logging type missing or wrong type")
-		return
-	}
-	logType, ok := ParseLogType(logTypeOption.Value.(string))
-	if !ok {
-		handleParseError("Unknown logging type")
-		return
-	}
-
-	settings := GuildLoggingSetting{}
-
-	dbResult := m.db.Where(&GuildLoggingSetting{GuildID: interaction.GuildID, LogType: logType}).First(&settings)
-
-	if dbResult.Error != nil && !errors.Is(dbResult.Error, gorm.ErrRecordNotFound) {
-		m.logger.Error("Error fetching logging settings from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID))
-		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "An internal error occurred.",
-			},
-		})
-		if err != nil {
-			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
-		}
+	logType, details := parseLogTypeOption(optionMap)
+	if len(details) > 0 {
+		handleError(details)
 		return
 	}
 
-	if enabledOption, ok := optionMap[CommandOptionEnabled]; ok {
-		enabled := enabledOption.Value.(bool)
-		if !ok {
-			handleParseError("Enabled type not bool")
+	channelID := ""
+	if channelOption, ok := optionMap[CommandOptionChannel]; ok {
+		channelID, details = m.parseChannelOption(interaction.GuildID, channelOption)
+		if len(details) > 0 {
+			handleError(details)
 			return
 		}
+	}
 
-		settings.Enabled = enabled
+	var destinations []GuildLoggingDestination
+	dbResult := m.db.Where(&GuildLoggingDestination{GuildID: interaction.GuildID, LogType: logType}).Order("id").Find(&destinations)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching logging destinations from db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(dbResult.Error))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
+		return
 	}
 
-	if formatOption, ok := optionMap[CommandOptionFormat]; ok {
-		format, ok := formatOption.Value.(string)
-		if !ok {
-			handleParseError("Format type not string")
+	_, hasEnabled := optionMap[CommandOptionEnabled]
+	_, hasFormat := optionMap[CommandOptionFormat]
+	_, hasOutputMode := optionMap[CommandOptionOutputMode]
+
+	if !hasEnabled && !hasFormat && !hasOutputMode {
+		// the channel subcommand predates multiple destinations and only works on types with a single destination
+		if len(channelID) == 0 {
+			handleError("There was an error parsing your command inputs.")
 			return
 		}
-
-		settings.Format = format
-	}
-
-	if channelOption, ok := optionMap[CommandOptionChannel]; ok {
-		if channelOption.Type != discordgo.ApplicationCommandOptionChannel {
-			handleParseError("Channel argument not channel")
+		switch len(destinations) {
+		case 0:
+			destinations = append(destinations, GuildLoggingDestination{
+				GuildID:    interaction.GuildID,
+				LogType:    logType,
+				Format:     defaultLoggingFormats[logType],
+				OutputMode: OutputModeText,
+			})
+		case 1:
+		default:
+			handleError("This logging type has multiple destinations, use `/logging destination add` and `/logging destination remove` instead.")
 			return
 		}
-
-		channel := channelOption.ChannelValue(m.discord)
-		if channel.GuildID != interaction.GuildID {
-			handleParseError("Channel is not in the same server")
+		destinations[0].ChannelID = channelID
+	} else {
+		if len(channelID) > 0 {
+			var channelDestinations []GuildLoggingDestination
+			for _, destination := range destinations {
+				if destination.ChannelID == channelID {
+					channelDestinations = append(channelDestinations, destination)
+				}
+			}
+			destinations = channelDestinations
+		}
+		if len(destinations) == 0 {
+			handleError("No matching destination is configured for this logging type, add one with `/logging destination add`.")
 			return
 		}
-		settings.LoggingChannelID = channel.ID
-	}
 
-	if dbResult.RowsAffected == 0 {
-		settings.Format = defaultLoggingFormats[logType]
-		settings.GuildID = interaction.GuildID
-		settings.LogType = logType
-		dbResult = m.db.Create(&settings)
-	} else {
-		dbResult = m.db.Save(&settings)
+		for i := range destinations {
+			if enabledOption, ok := optionMap[CommandOptionEnabled]; ok {
+				enabled, ok := enabledOption.Value.(bool)
+				if !ok {
+					handleError("There was an error parsing your command inputs.")
+					return
+				}
+				destinations[i].Enabled = enabled
+			}
+
+			if formatOption, ok := optionMap[CommandOptionFormat]; ok {
+				format, ok := formatOption.Value.(string)
+				if !ok {
+					handleError("There was an error parsing your command inputs.")
+					return
+				}
+				if err := validateLogFormat(logType, format); err != nil {
+					handleError("The format is invalid: " + err.Error() + "\nAvailable placeholders: " + formatPlaceholderList(logType))
+					return
+				}
+				destinations[i].Format = format
+			}
+
+			if outputModeOption, ok := optionMap[CommandOptionOutputMode]; ok {
+				outputMode, details := parseOutputModeOption(outputModeOption)
+				if len(details) > 0 {
+					handleError(details)
+					return
+				}
+				destinations[i].OutputMode = outputMode
+			}
+		}
 	}
 
-	if dbResult.Error != nil {
-		m.logger.Error("Error updating logging settings in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID))
-		err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: "An internal error occurred.",
-			},
-		})
-		if err != nil {
-			m.logger.Error("Error responding to interaction", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for i := range destinations {
+			err := tx.Save(&destinations[i]).Error
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		m.logger.Error("Error updating logging destinations in db", zap.String("guild", interaction.GuildID), zap.String("interaction", interaction.ID), zap.Error(err))
+		handleError("An internal error occurred. [" + interaction.ID + "]")
 		return
 	}
+	if _, ok := optionMap[CommandOptionEnabled]; ok && logType == MemberJoin {
+		// uses of invites are not tracked while join logging is disabled
+		go m.refreshInviteSnapshot(interaction.GuildID)
+	}
 
-	loggingChannelStatus := "None"
-	if len(settings.LoggingChannelID) > 0 {
-		loggingChannelStatus = "<#" + settings.LoggingChannelID + ">"
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name:  "Type",
+			Value: logType.ToReadableString(),
+		},
+		{
+			Name:  "Destinations",
+			Value: formatDestinationList(destinations),
+		},
+	}
+	if len(destinations) == 1 || hasFormat {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Format",
+			Value: destinations[0].Format,
+		})
 	}
 
-	err := m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
+	err = m.discord.InteractionRespond(interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			Embeds: []*discordgo.MessageEmbed{
 				{
-					Type:  discordgo.EmbedTypeRich,
-					Title: "Logging Settings updated!",
-					Fields: []*discordgo.MessageEmbedField{
-						{
-							Name:  "Type",
-							Value: settings.LogType.ToReadableString(),
-						},
-						{
-							Name:  "Enabled",
-							Value: strconv.FormatBool(settings.Enabled),
-						},
-						{
-							Name:  "Channel",
-							Value: loggingChannelStatus,
-						},
-						{
-							Name:  "Format",
-							Value: settings.Format,
-						},
-					},
+					Type:      discordgo.EmbedTypeRich,
+					Title:     "Logging Settings updated!",
+					Fields:    fields,
 					Color:     util.EmbedColorOK,
 					Timestamp: time.Now().Format(time.RFC3339),
 					Footer: &discordgo.MessageEmbedFooter{
@@ -166,3 +207,57 @@ func (m *Module) handleLoggingUpdateCommand(interaction *discordgo.Interaction,
 		m.logger.Error("Error responding to interaction", zap.Any("guild", interaction.GuildID), zap.Any("interaction", interaction.ID), zap.Error(err))
 	}
 }
+
+func parseLogTypeOption(optionMap map[string]*discordgo.ApplicationCommandInteractionDataOption) (LogType, string) {
+	logTypeOption, ok := optionMap[CommandOptionLoggingType]
+	if !ok || logTypeOption.Type != discordgo.ApplicationCommandOptionString {
+		return "", "Logging type missing or invalid."
+	}
+	logType, ok := ParseLogType(logTypeOption.StringValue())
+	if !ok {
+		return "", "Unknown logging type."
+	}
+	return logType, ""
+}
+
+func (m *Module) parseChannelOption(guildID string, channelOption *discordgo.ApplicationCommandInteractionDataOption) (string, string) {
+	if channelOption.Type != discordgo.ApplicationCommandOptionChannel {
+		return "", "Channel argument is not a channel."
+	}
+	channel := channelOption.ChannelValue(m.discord)
+	if channel == nil || channel.GuildID != guildID {
+		return "", "Channel is not in this server."
+	}
+	return channel.ID, ""
+}
+
+func parseOutputModeOption(outputModeOption *discordgo.ApplicationCommandInteractionDataOption) (LogOutputMode, string) {
+	outputModeStr, ok := outputModeOption.Value.(string)
+	if !ok {
+		return "", "Output mode missing or invalid."
+	}
+	outputMode, ok := ParseLogOutputMode(outputModeStr)
+	if !ok {
+		return "", "Unknown output mode."
+	}
+	return outputMode, ""
+}
+
+func formatDestination(destination GuildLoggingDestination) string {
+	state := "Disabled"
+	if destination.Enabled {
+		state = "Enabled"
+	}
+	return "<#" + destination.ChannelID + "> (" + state + ", " + destination.OutputMode.ToReadableString() + ")"
+}
+
+func formatDestinationList(destinations []GuildLoggingDestination) string {
+	if len(destinations) == 0 {
+		return "None"
+	}
+	lines := make([]string, len(destinations))
+	for i, destination := range destinations {
+		lines[i] = formatDestination(destination)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,19 +4,71 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/yannismate/gowlbot/internal/discord"
 	"github.com/yannismate/gowlbot/internal/util"
+	"strings"
 )
 
 const (
-	CommandNameLogging       = "logging"
-	CommandOptionStatus      = "status"
-	CommandOptionUpdate      = "update"
-	CommandOptionLoggingType = "logging_type"
-	CommandOptionEnabledCmd  = "enabled"
-	CommandOptionEnabled     = "enabled"
-	CommandOptionFormatCmd   = "format"
-	CommandOptionFormat      = "format"
-	CommandOptionChannelCmd  = "channel"
-	CommandOptionChannel     = "channel"
+	CommandNameLogging         = "logging"
+	CommandNameEditHistory     = "Edit history"
+	CommandOptionStatus        = "status"
+	CommandOptionUpdate        = "update"
+	CommandOptionPreview       = "preview"
+	CommandOptionLoggingType   = "logging_type"
+	CommandOptionEnabledCmd    = "enabled"
+	CommandOptionEnabled       = "enabled"
+	CommandOptionFormatCmd     = "format"
+	CommandOptionFormat        = "format"
+	CommandOptionChannelCmd    = "channel"
+	CommandOptionChannel       = "channel"
+	CommandOptionOutputModeCmd = "output_mode"
+	CommandOptionOutputMode    = "output_mode"
+
+	CommandOptionDestination          = "destination"
+	CommandOptionDestinationAddCmd    = "add"
+	CommandOptionDestinationRemoveCmd = "remove"
+	CommandOptionDestinationListCmd   = "list"
+
+	CommandOptionIgnore          = "ignore"
+	CommandOptionIgnoreAddCmd    = "add"
+	CommandOptionIgnoreRemoveCmd = "remove"
+	CommandOptionIgnoreListCmd   = "list"
+	CommandOptionIgnoreID        = "id"
+	CommandOptionRole            = "role"
+	CommandOptionUser            = "user"
+	CommandOptionBots            = "bots"
+	CommandOptionWebhooks        = "webhooks"
+
+	CommandOptionSearch        = "search"
+	CommandOptionAfter         = "after"
+	CommandOptionBefore        = "before"
+	CommandOptionRetention     = "retention"
+	CommandOptionRetentionDays = "days"
+
+	CommandOptionExport       = "export"
+	CommandOptionExportFormat = "file_format"
+	CommandOptionLoggingTypes = "logging_types"
+
+	CommandOptionWarmup         = "warmup"
+	CommandOptionWarmupMessages = "messages"
+
+	CommandOptionGhostPing       = "ghostping"
+	CommandOptionGhostPingWindow = "window_seconds"
+	CommandOptionGhostPingNotice = "public_notice"
+
+	CommandOptionCache                 = "cache"
+	CommandOptionCacheSettingsCmd      = "settings"
+	CommandOptionCacheAddChannelCmd    = "add_channel"
+	CommandOptionCacheRemoveChannelCmd = "remove_channel"
+	CommandOptionCacheTTL              = "ttl_minutes"
+	CommandOptionCacheContent          = "cache_content"
+
+	CommandOptionRedaction          = "redaction"
+	CommandOptionRedactionAddCmd    = "add"
+	CommandOptionRedactionRemoveCmd = "remove"
+	CommandOptionRedactionListCmd   = "list"
+	CommandOptionRedactionDetector  = "detector"
+	CommandOptionRedactionPattern   = "pattern"
+	CommandOptionRedactionID        = "id"
 )
 
 func (m *Module) registerSlashCommandListeners() {
@@ -24,11 +76,30 @@ func (m *Module) registerSlashCommandListeners() {
 }
 
 func (m *Module) handleInteractionCreation(_ *discordgo.Session, interaction *discordgo.InteractionCreate) {
+	if interaction.Type == discordgo.InteractionMessageComponent {
+		customID := interaction.MessageComponentData().CustomID
+		if strings.HasPrefix(customID, searchCustomIDPrefix) {
+			m.handleLoggingSearchPageButton(interaction.Interaction, customID)
+		} else if strings.HasPrefix(customID, historyCustomIDPrefix) {
+			m.handleEditHistoryPageButton(interaction.Interaction, customID)
+		}
+		return
+	}
+	if interaction.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		if interaction.ApplicationCommandData().Name == CommandNameLogging {
+			m.handleLoggingAutocomplete(interaction.Interaction)
+		}
+		return
+	}
 	if interaction.Type != discordgo.InteractionApplicationCommand {
 		return
 	}
 	data := interaction.Data.(discordgo.ApplicationCommandInteractionData)
 
+	if data.Name == CommandNameEditHistory {
+		m.handleEditHistoryCommand(interaction.Interaction, data.TargetID)
+		return
+	}
 	if data.Name != CommandNameLogging {
 		return
 	}
@@ -39,49 +110,67 @@ func (m *Module) handleInteractionCreation(_ *discordgo.Session, interaction *di
 		m.handleLoggingStatusCommand(interaction.Interaction)
 	} else if _, ok = optionMap[CommandOptionUpdate]; ok {
 		m.handleLoggingUpdateCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionPreview]; ok {
+		m.handleLoggingPreviewCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionDestination]; ok {
+		m.handleLoggingDestinationCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionIgnore]; ok {
+		m.handleLoggingIgnoreCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionSearch]; ok {
+		m.handleLoggingSearchCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionRetention]; ok {
+		m.handleLoggingRetentionCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionExport]; ok {
+		m.handleLoggingExportCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionWarmup]; ok {
+		m.handleLoggingWarmupCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionGhostPing]; ok {
+		m.handleLoggingGhostPingCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionCache]; ok {
+		m.handleLoggingCacheCommand(interaction.Interaction, optionMap)
+	} else if _, ok = optionMap[CommandOptionRedaction]; ok {
+		m.handleLoggingRedactionCommand(interaction.Interaction, optionMap)
 	}
 }
 
 func (m *Module) GetSlashCommands() []discord.VersionedSlashCommand {
 	var cmdDmPermission = false
 	var adminMemberPermission int64 = discordgo.PermissionAdministrator
-	var version = "logging-1.6"
+	var version = "logging-1.24"
+	var editHistoryVersion = "edit-history-1.0"
+	var moderatorMemberPermission int64 = discordgo.PermissionManageMessages
+	var minRetentionDays float64 = 0
+	var minWarmupMessages float64 = 0
+	var minGhostPingWindow float64 = 0
+	var minCacheTTLMinutes float64 = 1
 
 	loggingTypeOption := discordgo.ApplicationCommandOption{
 		Name:        CommandOptionLoggingType,
 		Description: "Logging Type",
 		Type:        discordgo.ApplicationCommandOptionString,
-		Choices: []*discordgo.ApplicationCommandOptionChoice{
-			{
-				Name:  MessageEdit.ToReadableString(),
-				Value: MessageEdit,
-			},
-			{
-				Name:  MessageDelete.ToReadableString(),
-				Value: MessageDelete,
-			},
-			{
-				Name:  MemberJoin.ToReadableString(),
-				Value: MemberJoin,
-			},
-			{
-				Name:  MemberLeave.ToReadableString(),
-				Value: MemberLeave,
-			},
-			{
-				Name:  MemberRoleChange.ToReadableString(),
-				Value: MemberRoleChange,
-			},
-			{
-				Name:  GuildBanAdd.ToReadableString(),
-				Value: GuildBanAdd,
-			},
-			{
-				Name:  GuildBanRemove.ToReadableString(),
-				Value: GuildBanRemove,
-			},
+		// there are more logging types than discord allows choices
+		Autocomplete: true,
+		Required:     true,
+	}
+
+	optionalLoggingTypeOption := loggingTypeOption
+	optionalLoggingTypeOption.Required = false
+
+	outputModeChoices := []*discordgo.ApplicationCommandOptionChoice{
+		{
+			Name:  OutputModeText.ToReadableString(),
+			Value: OutputModeText,
 		},
-		Required: true,
+		{
+			Name:  OutputModeEmbed.ToReadableString(),
+			Value: OutputModeEmbed,
+		},
+	}
+
+	destinationChannelOption := discordgo.ApplicationCommandOption{
+		Name:        CommandOptionChannel,
+		Description: "Only update the destination in this channel",
+		Type:        discordgo.ApplicationCommandOptionChannel,
 	}
 
 	newLoggingCmd := discordgo.ApplicationCommand{
@@ -113,6 +202,7 @@ func (m *Module) GetSlashCommands() []discord.VersionedSlashCommand {
 								Type:        discordgo.ApplicationCommandOptionBoolean,
 								Required:    true,
 							},
+							&destinationChannelOption,
 						},
 					},
 					{
@@ -127,6 +217,7 @@ func (m *Module) GetSlashCommands() []discord.VersionedSlashCommand {
 								Type:        discordgo.ApplicationCommandOptionString,
 								Required:    true,
 							},
+							&destinationChannelOption,
 						},
 					},
 					{
@@ -143,16 +234,411 @@ func (m *Module) GetSlashCommands() []discord.VersionedSlashCommand {
 							},
 						},
 					},
+					{
+						Name:        CommandOptionOutputModeCmd,
+						Description: "Set whether logs are sent as plain text or as embeds",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							&loggingTypeOption,
+							{
+								Name:        CommandOptionOutputMode,
+								Description: "Output Mode",
+								Type:        discordgo.ApplicationCommandOptionString,
+								Choices:     outputModeChoices,
+								Required:    true,
+							},
+							&destinationChannelOption,
+						},
+					},
+				},
+			},
+			{
+				Name:        CommandOptionPreview,
+				Description: "Preview a logging format with sample data",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					&loggingTypeOption,
+					{
+						Name:        CommandOptionFormat,
+						Description: "Draft format, defaults to the configured format",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:        CommandOptionOutputMode,
+						Description: "Output Mode, defaults to the configured output mode",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices:     outputModeChoices,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionDestination,
+				Description: "Manage the channels logs of a specific type are sent to",
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionDestinationAddCmd,
+						Description: "Send logs of this type to an additional channel",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							&loggingTypeOption,
+							{
+								Name:        CommandOptionChannel,
+								Description: "Channel",
+								Type:        discordgo.ApplicationCommandOptionChannel,
+								Required:    true,
+							},
+							{
+								Name:        CommandOptionFormat,
+								Description: "Format, defaults to the built-in format",
+								Type:        discordgo.ApplicationCommandOptionString,
+							},
+							{
+								Name:        CommandOptionOutputMode,
+								Description: "Output Mode, defaults to text",
+								Type:        discordgo.ApplicationCommandOptionString,
+								Choices:     outputModeChoices,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionDestinationRemoveCmd,
+						Description: "Stop sending logs of this type to a channel",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							&loggingTypeOption,
+							{
+								Name:        CommandOptionChannel,
+								Description: "Channel",
+								Type:        discordgo.ApplicationCommandOptionChannel,
+								Required:    true,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionDestinationListCmd,
+						Description: "List all logging destinations",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							&optionalLoggingTypeOption,
+						},
+					},
+				},
+			},
+			{
+				Name:        CommandOptionIgnore,
+				Description: "Exclude channels, categories, roles, users, bots or webhooks from logging",
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionIgnoreAddCmd,
+						Description: "Add an ignore rule, specify exactly one target",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionChannel,
+								Description: "Ignore a channel or a whole category",
+								Type:        discordgo.ApplicationCommandOptionChannel,
+							},
+							{
+								Name:        CommandOptionRole,
+								Description: "Ignore members with this role",
+								Type:        discordgo.ApplicationCommandOptionRole,
+							},
+							{
+								Name:        CommandOptionUser,
+								Description: "Ignore a specific user",
+								Type:        discordgo.ApplicationCommandOptionUser,
+							},
+							{
+								Name:        CommandOptionBots,
+								Description: "Ignore all bots",
+								Type:        discordgo.ApplicationCommandOptionBoolean,
+							},
+							{
+								Name:        CommandOptionWebhooks,
+								Description: "Ignore all webhook messages",
+								Type:        discordgo.ApplicationCommandOptionBoolean,
+							},
+							{
+								Name:         CommandOptionLoggingType,
+								Description:  "Only ignore this logging type, defaults to all types",
+								Type:         discordgo.ApplicationCommandOptionString,
+								Autocomplete: true,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionIgnoreRemoveCmd,
+						Description: "Remove an ignore rule",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionIgnoreID,
+								Description: "Ignore rule ID, see /logging ignore list",
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								Required:    true,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionIgnoreListCmd,
+						Description: "List all ignore rules",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionSearch,
+				Description: "Search archived log events",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionUser,
+						Description: "User who caused or was affected by the event",
+						Type:        discordgo.ApplicationCommandOptionUser,
+					},
+					&optionalLoggingTypeOption,
+					{
+						Name:        CommandOptionChannel,
+						Description: "Channel the event happened in",
+						Type:        discordgo.ApplicationCommandOptionChannel,
+					},
+					{
+						Name:        CommandOptionAfter,
+						Description: "Only events after this time, e.g. 7d, 12h or 2023-01-31",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:        CommandOptionBefore,
+						Description: "Only events before this time, e.g. 7d, 12h or 2023-01-31",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionRetention,
+				Description: "Show or change how long log events are archived",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionRetentionDays,
+						Description: "Days to keep log events, 0 disables the archive",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minRetentionDays,
+						MaxValue:    maxArchiveRetentionDays,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionWarmup,
+				Description: "Show or change how many messages per channel are cached on startup",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionWarmupMessages,
+						Description: "Messages per channel, 0 disables the warm-up",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minWarmupMessages,
+						MaxValue:    maxCacheWarmupMessages,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionGhostPing,
+				Description: "Show or change how ghost pings are detected",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionGhostPingWindow,
+						Description: "Seconds after sending in which removed mentions count as ghost ping",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minGhostPingWindow,
+						MaxValue:    maxGhostPingWindowSeconds,
+					},
+					{
+						Name:        CommandOptionGhostPingNotice,
+						Description: "Also name the author and pinged members in the channel of the message",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionCache,
+				Description: "Manage how long and in which channels messages are cached",
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionCacheSettingsCmd,
+						Description: "Show or change the message cache settings",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionCacheTTL,
+								Description: "Minutes messages are cached for",
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								MinValue:    &minCacheTTLMinutes,
+							},
+							{
+								Name:        CommandOptionCacheContent,
+								Description: "Whether message content is cached, only authors and mentions are cached otherwise",
+								Type:        discordgo.ApplicationCommandOptionBoolean,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionCacheAddChannelCmd,
+						Description: "Only cache message content in the listed channels, threads and categories",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionChannel,
+								Description: "Channel or category",
+								Type:        discordgo.ApplicationCommandOptionChannel,
+								Required:    true,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionCacheRemoveChannelCmd,
+						Description: "Remove a channel from the cached channels, all channels are cached if none are left",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionChannel,
+								Description: "Channel or category",
+								Type:        discordgo.ApplicationCommandOptionChannel,
+								Required:    true,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:        CommandOptionRedaction,
+				Description: "Remove personal data like email addresses from logged message content",
+				Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionRedactionAddCmd,
+						Description: "Add a redaction rule",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionRedactionDetector,
+								Description: "What to redact",
+								Type:        discordgo.ApplicationCommandOptionString,
+								Required:    true,
+								Choices: []*discordgo.ApplicationCommandOptionChoice{
+									{
+										Name:  RedactionDetectorEmail.ToReadableString(),
+										Value: RedactionDetectorEmail,
+									},
+									{
+										Name:  RedactionDetectorPhone.ToReadableString(),
+										Value: RedactionDetectorPhone,
+									},
+									{
+										Name:  RedactionDetectorIBAN.ToReadableString(),
+										Value: RedactionDetectorIBAN,
+									},
+									{
+										Name:  RedactionDetectorDiscordToken.ToReadableString(),
+										Value: RedactionDetectorDiscordToken,
+									},
+									{
+										Name:  RedactionDetectorCustom.ToReadableString(),
+										Value: RedactionDetectorCustom,
+									},
+								},
+							},
+							{
+								Name:        CommandOptionRedactionPattern,
+								Description: "Regular expression for custom rules, e.g. (?i)order-\\d{6}",
+								Type:        discordgo.ApplicationCommandOptionString,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionRedactionRemoveCmd,
+						Description: "Remove a redaction rule",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+						Options: []*discordgo.ApplicationCommandOption{
+							{
+								Name:        CommandOptionRedactionID,
+								Description: "Redaction rule ID, see /logging redaction list",
+								Type:        discordgo.ApplicationCommandOptionInteger,
+								Required:    true,
+							},
+						},
+					},
+					{
+						Name:        CommandOptionRedactionListCmd,
+						Description: "List all redaction rules",
+						Type:        discordgo.ApplicationCommandOptionSubCommand,
+					},
+				},
+			},
+			{
+				Name:        CommandOptionExport,
+				Description: "Export archived log events as a file",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Name:        CommandOptionExportFormat,
+						Description: "File format",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{
+								Name:  "JSON Lines",
+								Value: ExportFormatJSONL,
+							},
+							{
+								Name:  "CSV",
+								Value: ExportFormatCSV,
+							},
+						},
+					},
+					{
+						Name:         CommandOptionLoggingTypes,
+						Description:  "Comma separated logging types, e.g. message_delete,member_kick. Defaults to all types",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: true,
+					},
+					{
+						Name:        CommandOptionAfter,
+						Description: "Only events after this time, e.g. 7d, 12h or 2023-01-31",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:        CommandOptionBefore,
+						Description: "Only events before this time, e.g. 7d, 12h or 2023-01-31",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
 				},
 			},
 		},
 	}
 
+	editHistoryCmd := discordgo.ApplicationCommand{
+		Name:                     CommandNameEditHistory,
+		Version:                  editHistoryVersion,
+		Type:                     discordgo.MessageApplicationCommand,
+		DefaultMemberPermissions: &moderatorMemberPermission,
+		DMPermission:             &cmdDmPermission,
+	}
+
 	return []discord.VersionedSlashCommand{
 		{
 			Command: newLoggingCmd,
 			CmdName: CommandNameLogging,
 			Version: version,
 		},
+		{
+			Command: editHistoryCmd,
+			CmdName: CommandNameEditHistory,
+			Version: editHistoryVersion,
+		},
 	}
 }
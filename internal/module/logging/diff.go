@@ -0,0 +1,224 @@
+package logging
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// token limit for the quadratic diff, longer texts are shown as a full replacement
+	diffMaxTokens = 500
+
+	ansiRed   = "\u001b[31m"
+	ansiGreen = "\u001b[32m"
+	ansiReset = "\u001b[0m"
+)
+
+type diffOperation int
+
+const (
+	diffEqual diffOperation = iota
+	diffInsert
+	diffDelete
+)
+
+type diffSegment struct {
+	Operation diffOperation
+	Text      string
+}
+
+// tokenizeWords splits text into alternating runs of whitespace and non whitespace, so joining the tokens
+// results in the original text.
+func tokenizeWords(text string) []string {
+	var tokens []string
+	var current strings.Builder
+	currentIsSpace := false
+
+	for _, r := range text {
+		isSpace := unicode.IsSpace(r)
+		if current.Len() > 0 && isSpace != currentIsSpace {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+		currentIsSpace = isSpace
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+func diffWords(oldText string, newText string) []diffSegment {
+	oldTokens := tokenizeWords(oldText)
+	newTokens := tokenizeWords(newText)
+
+	prefixLength := 0
+	for prefixLength < len(oldTokens) && prefixLength < len(newTokens) && oldTokens[prefixLength] == newTokens[prefixLength] {
+		prefixLength++
+	}
+	suffixLength := 0
+	for suffixLength < len(oldTokens)-prefixLength && suffixLength < len(newTokens)-prefixLength &&
+		oldTokens[len(oldTokens)-1-suffixLength] == newTokens[len(newTokens)-1-suffixLength] {
+		suffixLength++
+	}
+
+	var segments []diffSegment
+	segments = appendSegment(segments, diffEqual, strings.Join(oldTokens[:prefixLength], ""))
+
+	oldMiddle := oldTokens[prefixLength : len(oldTokens)-suffixLength]
+	newMiddle := newTokens[prefixLength : len(newTokens)-suffixLength]
+	if len(oldMiddle) > diffMaxTokens || len(newMiddle) > diffMaxTokens {
+		segments = appendSegment(segments, diffDelete, strings.Join(oldMiddle, ""))
+		segments = appendSegment(segments, diffInsert, strings.Join(newMiddle, ""))
+	} else {
+		segments = append(segments, diffTokens(oldMiddle, newMiddle)...)
+	}
+
+	segments = appendSegment(segments, diffEqual, strings.Join(oldTokens[len(oldTokens)-suffixLength:], ""))
+	return segments
+}
+
+// diffTokens computes the longest common subsequence of both token lists and derives the edit script from it.
+func diffTokens(oldTokens []string, newTokens []string) []diffSegment {
+	lcs := make([][]int, len(oldTokens)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newTokens)+1)
+	}
+	for i := len(oldTokens) - 1; i >= 0; i-- {
+		for j := len(newTokens) - 1; j >= 0; j-- {
+			if oldTokens[i] == newTokens[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []diffSegment
+	i, j := 0, 0
+	for i < len(oldTokens) && j < len(newTokens) {
+		if oldTokens[i] == newTokens[j] {
+			segments = appendSegment(segments, diffEqual, oldTokens[i])
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			segments = appendSegment(segments, diffDelete, oldTokens[i])
+			i++
+		} else {
+			segments = appendSegment(segments, diffInsert, newTokens[j])
+			j++
+		}
+	}
+	for ; i < len(oldTokens); i++ {
+		segments = appendSegment(segments, diffDelete, oldTokens[i])
+	}
+	for ; j < len(newTokens); j++ {
+		segments = appendSegment(segments, diffInsert, newTokens[j])
+	}
+	return segments
+}
+
+func appendSegment(segments []diffSegment, operation diffOperation, text string) []diffSegment {
+	if len(text) == 0 {
+		return segments
+	}
+	if len(segments) > 0 && segments[len(segments)-1].Operation == operation {
+		segments[len(segments)-1].Text += text
+		return segments
+	}
+	return append(segments, diffSegment{Operation: operation, Text: text})
+}
+
+// renderDiffANSI renders the diff for an ansi code block, with deletions in red and insertions in green.
+func renderDiffANSI(segments []diffSegment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		switch segment.Operation {
+		case diffEqual:
+			sb.WriteString(segment.Text)
+		case diffDelete:
+			sb.WriteString(ansiRed + segment.Text + ansiReset)
+		case diffInsert:
+			sb.WriteString(ansiGreen + segment.Text + ansiReset)
+		}
+	}
+	return sb.String()
+}
+
+// renderDiffPlain renders the diff using git's word diff markers, for clients that do not support ansi code blocks.
+func renderDiffPlain(segments []diffSegment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		switch segment.Operation {
+		case diffEqual:
+			sb.WriteString(segment.Text)
+		case diffDelete:
+			sb.WriteString("[-" + segment.Text + "-]")
+		case diffInsert:
+			sb.WriteString("{+" + segment.Text + "+}")
+		}
+	}
+	return sb.String()
+}
+
+// substringANSI works like substringUTF8, but only splits between escape sequences and text. A color that is active at
+// the bounds is reset at the end and repeated at the start, so every part renders like it did in the full diff.
+func substringANSI(s string, start int, end int) string {
+	runes := []rune(s)
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	// escapeStart maps every rune inside an escape sequence to the start of the sequence, -1 for text
+	escapeStart := make([]int, len(runes))
+	activeColor := make([]string, len(runes)+1)
+	color := ""
+	for i := 0; i < len(runes); {
+		activeColor[i] = color
+		escapeStart[i] = -1
+		if runes[i] != '\u001b' {
+			i++
+			continue
+		}
+		sequenceEnd := i
+		for sequenceEnd < len(runes)-1 && runes[sequenceEnd] != 'm' {
+			sequenceEnd++
+		}
+		for j := i; j <= sequenceEnd; j++ {
+			escapeStart[j] = i
+			activeColor[j] = color
+		}
+		sequence := string(runes[i : sequenceEnd+1])
+		if sequence == ansiReset {
+			color = ""
+		} else {
+			color = sequence
+		}
+		i = sequenceEnd + 1
+	}
+	activeColor[len(runes)] = color
+
+	adjust := func(idx int) int {
+		if idx < len(runes) && escapeStart[idx] >= 0 {
+			return escapeStart[idx]
+		}
+		return idx
+	}
+	start = adjust(start)
+	end = adjust(end)
+	if start >= end {
+		return ""
+	}
+
+	result := string(runes[start:end])
+	if escapeStart[start] < 0 {
+		result = activeColor[start] + result
+	}
+	if len(activeColor[end]) > 0 {
+		result += ansiReset
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"gorm.io/gorm"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatJSONL ExportFormat = "jsonl"
+	ExportFormatCSV   ExportFormat = "csv"
+)
+
+const exportBatchSize = 500
+
+func ParseExportFormat(str string) (ExportFormat, bool) {
+	switch ExportFormat(str) {
+	case ExportFormatJSONL, ExportFormatCSV:
+		return ExportFormat(str), true
+	}
+	return "", false
+}
+
+func (ef ExportFormat) FileExtension() string {
+	return string(ef)
+}
+
+type exportedLogRecord struct {
+	ID        uint              `json:"id"`
+	CreatedAt time.Time         `json:"created_at"`
+	GuildID   string            `json:"guild_id"`
+	LogType   LogType           `json:"log_type"`
+	ActorID   string            `json:"actor_id,omitempty"`
+	TargetID  string            `json:"target_id,omitempty"`
+	ChannelID string            `json:"channel_id,omitempty"`
+	Data      map[string]string `json:"data"`
+}
+
+// ExportLogRecords writes all records matching the query in chronological order. A new chunk is requested from
+// nextChunk before a chunk would grow beyond maxChunkSize bytes, 0 writes everything into a single chunk.
+// It returns the number of exported records.
+func ExportLogRecords(db *gorm.DB, query LogRecordQuery, format ExportFormat, maxChunkSize int, nextChunk func() (io.Writer, error)) (int, error) {
+	encoder := newLogRecordEncoder(format, query.LogTypes)
+	writer := chunkedWriter{maxSize: maxChunkSize, header: encoder.header(), nextChunk: nextChunk}
+
+	count := 0
+	var records []LogRecord
+	result := buildLogRecordQuery(db, query).FindInBatches(&records, exportBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, record := range records {
+			entry, err := encoder.encode(record)
+			if err != nil {
+				return err
+			}
+			err = writer.writeEntry(entry)
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return count, result.Error
+	}
+
+	// always produce a file, so empty exports still contain the csv header
+	if !writer.started {
+		err := writer.startChunk()
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+type chunkedWriter struct {
+	maxSize   int
+	header    []byte
+	nextChunk func() (io.Writer, error)
+	current   io.Writer
+	size      int
+	started   bool
+}
+
+func (w *chunkedWriter) startChunk() error {
+	current, err := w.nextChunk()
+	if err != nil {
+		return err
+	}
+	w.current = current
+	w.started = true
+	w.size = len(w.header)
+	_, err = w.current.Write(w.header)
+	return err
+}
+
+// writeEntry never splits an entry, an entry that is larger than a whole chunk gets a chunk of its own.
+func (w *chunkedWriter) writeEntry(entry []byte) error {
+	if !w.started || (w.maxSize > 0 && w.size+len(entry) > w.maxSize && w.size > len(w.header)) {
+		err := w.startChunk()
+		if err != nil {
+			return err
+		}
+	}
+	w.size += len(entry)
+	_, err := w.current.Write(entry)
+	return err
+}
+
+type logRecordEncoder struct {
+	format  ExportFormat
+	columns []string
+}
+
+// newLogRecordEncoder creates an encoder for the given log types, all types if none are given. Csv files get one
+// column per placeholder of these types, so they can be filtered in spreadsheets.
+func newLogRecordEncoder(format ExportFormat, logTypes []LogType) *logRecordEncoder {
+	if len(logTypes) == 0 {
+		for logType := range logTypePlaceholders {
+			logTypes = append(logTypes, logType)
+		}
+	}
+
+	columnSet := make(map[string]bool)
+	for _, logType := range logTypes {
+		for _, placeholder := range getLogTypePlaceholders(logType) {
+			columnSet[placeholder] = true
+		}
+	}
+	// ansi escape codes are unreadable outside of discord, the others are fixed columns
+	delete(columnSet, "content_diff")
+	delete(columnSet, "time")
+	delete(columnSet, "channel_id")
+
+	var columns []string
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	return &logRecordEncoder{format: format, columns: columns}
+}
+
+func (e *logRecordEncoder) header() []byte {
+	if e.format != ExportFormatCSV {
+		return nil
+	}
+	return e.writeCSVRow(append([]string{"id", "created_at", "guild_id", "log_type", "actor_id", "target_id", "channel_id"}, e.columns...))
+}
+
+func (e *logRecordEncoder) encode(record LogRecord) ([]byte, error) {
+	data := make(map[string]string)
+	if len(record.Payload) > 0 {
+		err := json.Unmarshal([]byte(record.Payload), &data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	delete(data, "content_diff")
+
+	if e.format == ExportFormatCSV {
+		row := []string{
+			strconv.FormatUint(uint64(record.ID), 10),
+			record.CreatedAt.UTC().Format(time.RFC3339),
+			record.GuildID,
+			string(record.LogType),
+			record.ActorID,
+			record.TargetID,
+			record.ChannelID,
+		}
+		for _, column := range e.columns {
+			row = append(row, escapeSpreadsheetFormula(data[column]))
+		}
+		return e.writeCSVRow(row), nil
+	}
+
+	entry, err := json.Marshal(exportedLogRecord{
+		ID:        record.ID,
+		CreatedAt: record.CreatedAt.UTC(),
+		GuildID:   record.GuildID,
+		LogType:   record.LogType,
+		ActorID:   record.ActorID,
+		TargetID:  record.TargetID,
+		ChannelID: record.ChannelID,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(entry, '\n'), nil
+}
+
+func (e *logRecordEncoder) writeCSVRow(row []string) []byte {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write(row)
+	writer.Flush()
+	return buf.Bytes()
+}
+
+// escapeSpreadsheetFormula prevents user content like "=HYPERLINK(...)" from being evaluated when the file is
+// opened in a spreadsheet application.
+func escapeSpreadsheetFormula(value string) string {
+	if len(value) > 0 && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
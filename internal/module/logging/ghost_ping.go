@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	defaultGhostPingWindowSeconds = 300
+	maxGhostPingWindowSeconds     = 86400
+)
+
+type mentionSet struct {
+	UserIDs  []string
+	RoleIDs  []string
+	Everyone bool
+}
+
+func (ms mentionSet) isEmpty() bool {
+	return len(ms.UserIDs) == 0 && len(ms.RoleIDs) == 0 && !ms.Everyone
+}
+
+func (ms mentionSet) format() string {
+	var mentions []string
+	if ms.Everyone {
+		mentions = append(mentions, "@\u200Beveryone")
+	}
+	for _, userID := range ms.UserIDs {
+		mentions = append(mentions, "<@"+userID+">")
+	}
+	for _, roleID := range ms.RoleIDs {
+		mentions = append(mentions, "<@&"+roleID+">")
+	}
+	return formatOptionalList(mentions)
+}
+
+// mentions returns who was pinged by the message, authors mentioning themselves don't ping anyone.
+func (cm *CachedMessage) mentions() mentionSet {
+	set := mentionSet{RoleIDs: cm.MentionedRoleIDs, Everyone: cm.MentionsEveryone}
+	for _, userID := range cm.MentionedUserIDs {
+		if userID != cm.AuthorID {
+			set.UserIDs = append(set.UserIDs, userID)
+		}
+	}
+	return set
+}
+
+// removedMentions returns the mentions of the previous version that are missing in the edited one.
+func removedMentions(previous mentionSet, edited mentionSet) mentionSet {
+	removed := func(previousIDs []string, editedIDs []string) []string {
+		editedSet := make(map[string]bool)
+		for _, id := range editedIDs {
+			editedSet[id] = true
+		}
+		var result []string
+		for _, id := range previousIDs {
+			if !editedSet[id] {
+				result = append(result, id)
+			}
+		}
+		return result
+	}
+	return mentionSet{
+		UserIDs:  removed(previous.UserIDs, edited.UserIDs),
+		RoleIDs:  removed(previous.RoleIDs, edited.RoleIDs),
+		Everyone: previous.Everyone && !edited.Everyone,
+	}
+}
+
+func getGhostPingWindow(guildConfig GuildLoggingConfig) time.Duration {
+	seconds := defaultGhostPingWindowSeconds
+	if guildConfig.GhostPingWindowSeconds != nil {
+		seconds = *guildConfig.GhostPingWindowSeconds
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+// isGhostPingDetectionActive avoids the audit log lookups of deletions if neither logs nor notices are wanted.
+func (m *Module) isGhostPingDetectionActive(guildID string) bool {
+	return m.isLoggingEnabled(guildID, GhostPing) || m.getGuildLoggingConfig(guildID).GhostPingNotice
+}
+
+// checkGhostPing reports messages whose mentions were deleted or edited away shortly after they were sent.
+func (m *Module) checkGhostPing(guildID string, messageID string, cachedMsg CachedMessage, pinged mentionSet, action string) {
+	if pinged.isEmpty() {
+		return
+	}
+
+	createdAt := cachedMsg.CreatedAt
+	if createdAt.IsZero() {
+		createdAt, _ = discordgo.SnowflakeTimestamp(messageID)
+	}
+	guildConfig := m.getGuildLoggingConfig(guildID)
+	if time.Since(createdAt) > getGhostPingWindow(guildConfig) {
+		return
+	}
+
+	data := map[string]string{
+		"channel_id":       cachedMsg.ChannelID,
+		"author_id":        cachedMsg.AuthorID,
+		"author_full_name": cachedMsg.AuthorFullName,
+		"previous_content": cachedMsg.contentForLog(),
+	}
+	cachedMsg.addContextToLogData(messageID, data)
+	data["mentions"] = pinged.format()
+	data["ghost_ping_action"] = action
+
+	m.sendLogToDiscord(guildID, GhostPing, data)
+
+	if guildConfig.GhostPingNotice && !m.isIgnored(guildID, m.newIgnoreSubjectFromData(guildID, data), GhostPing) {
+		m.sendGhostPingNotice(cachedMsg, pinged)
+	}
+}
+
+// sendGhostPingNotice names the author and the pinged members and roles in the origin channel without pinging them
+// again.
+func (m *Module) sendGhostPingNotice(cachedMsg CachedMessage, pinged mentionSet) {
+	_, err := m.discord.ChannelMessageSendComplex(cachedMsg.ChannelID, &discordgo.MessageSend{
+		Content:         "👻 <@" + cachedMsg.AuthorID + "> ghost pinged " + pinged.format() + ".",
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		m.logger.Warn("Error sending ghost ping notice", zap.String("channel", cachedMsg.ChannelID), zap.Error(err))
+	}
+}
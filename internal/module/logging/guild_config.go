@@ -0,0 +1,34 @@
+package logging
+
+import "go.uber.org/zap"
+
+// getGuildLoggingConfig returns the guild settings, unset fields fall back to the bot config.
+func (m *Module) getGuildLoggingConfig(guildID string) GuildLoggingConfig {
+	if guildConfig, ok := m.guildConfigs.Get(guildID); ok {
+		return guildConfig
+	}
+
+	guildConfig := GuildLoggingConfig{GuildID: guildID}
+	result := m.db.Where(&GuildLoggingConfig{GuildID: guildID}).Limit(1).Find(&guildConfig)
+	if result.Error != nil {
+		m.logger.Error("Error fetching guild logging config from db", zap.String("guild", guildID), zap.Error(result.Error))
+		return guildConfig
+	}
+	m.guildConfigs.Set(guildID, guildConfig)
+	return guildConfig
+}
+
+func (m *Module) updateGuildLoggingConfig(guildID string, update func(guildConfig *GuildLoggingConfig)) error {
+	guildConfig := GuildLoggingConfig{}
+	result := m.db.Where(&GuildLoggingConfig{GuildID: guildID}).Limit(1).Find(&guildConfig)
+	if result.Error != nil {
+		return result.Error
+	}
+	guildConfig.GuildID = guildID
+	update(&guildConfig)
+	err := m.db.Save(&guildConfig).Error
+
+	m.guildConfigs.Delete(guildID)
+	m.cachePolicies.Delete(guildID)
+	return err
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// ignoreSubject describes where an event happened and who caused it, to match it against ignore rules.
+type ignoreSubject struct {
+	ChannelID       string
+	ParentChannelID string
+	CategoryID      string
+	UserID          string
+	RoleIDs         []string
+	Bot             bool
+	WebhookID       string
+}
+
+func (m *Module) newMessageIgnoreSubject(msg *discordgo.Message) ignoreSubject {
+	subject := m.newChannelIgnoreSubject(msg.ChannelID)
+	subject.WebhookID = msg.WebhookID
+	if msg.Author != nil {
+		subject.UserID = msg.Author.ID
+		subject.Bot = msg.Author.Bot
+	}
+	if msg.Member != nil {
+		subject.RoleIDs = msg.Member.Roles
+	} else if len(subject.UserID) > 0 {
+		subject.RoleIDs = m.getMemberRoleIDs(msg.GuildID, subject.UserID)
+	}
+	return subject
+}
+
+// newIgnoreSubjectFromData resolves the subject from the placeholders of a log, so every log type can be filtered.
+func (m *Module) newIgnoreSubjectFromData(guildID string, data map[string]string) ignoreSubject {
+	subject := m.newChannelIgnoreSubject(data["channel_id"])
+
+	subject.UserID = data["author_id"]
+	if len(subject.UserID) == 0 {
+		subject.UserID = data["member_id"]
+	}
+	if len(subject.UserID) > 0 {
+		if member, err := m.discord.State.Member(guildID, subject.UserID); err == nil {
+			subject.RoleIDs = member.Roles
+			subject.Bot = member.User != nil && member.User.Bot
+		}
+	}
+	return subject
+}
+
+func (m *Module) newChannelIgnoreSubject(channelID string) ignoreSubject {
+	subject := ignoreSubject{ChannelID: channelID}
+	if len(channelID) == 0 {
+		return subject
+	}
+
+	channel, err := m.discord.State.Channel(channelID)
+	if err != nil {
+		return subject
+	}
+	if channel.IsThread() {
+		subject.ParentChannelID = channel.ParentID
+		if parent, err := m.discord.State.Channel(channel.ParentID); err == nil {
+			subject.CategoryID = parent.ParentID
+		}
+	} else if channel.Type == discordgo.ChannelTypeGuildCategory {
+		subject.CategoryID = channel.ID
+	} else {
+		subject.CategoryID = channel.ParentID
+	}
+	return subject
+}
+
+func (m *Module) getMemberRoleIDs(guildID string, userID string) []string {
+	member, err := m.discord.State.Member(guildID, userID)
+	if err != nil {
+		return nil
+	}
+	return member.Roles
+}
+
+func (rule LoggingIgnoreRule) matches(subject ignoreSubject) bool {
+	switch rule.TargetType {
+	case IgnoreTargetChannel:
+		return rule.TargetID == subject.ChannelID || rule.TargetID == subject.ParentChannelID
+	case IgnoreTargetCategory:
+		return len(subject.CategoryID) > 0 && rule.TargetID == subject.CategoryID
+	case IgnoreTargetRole:
+		for _, roleID := range subject.RoleIDs {
+			if rule.TargetID == roleID {
+				return true
+			}
+		}
+		return false
+	case IgnoreTargetUser:
+		return len(subject.UserID) > 0 && rule.TargetID == subject.UserID
+	case IgnoreTargetBots:
+		return subject.Bot
+	case IgnoreTargetWebhooks:
+		return len(subject.WebhookID) > 0
+	}
+	return false
+}
+
+// isIgnored returns true if the subject is ignored for all given log types.
+func (m *Module) isIgnored(guildID string, subject ignoreSubject, logTypes ...LogType) bool {
+	rules, ok := m.ignoreRules.Get(guildID)
+	if !ok {
+		result := m.db.Where(&LoggingIgnoreRule{GuildID: guildID}).Find(&rules)
+		if result.Error != nil {
+			m.logger.Error("Error fetching logging ignore rules from db", zap.String("guild", guildID), zap.Error(result.Error))
+			return false
+		}
+		m.ignoreRules.Set(guildID, rules)
+	}
+	if len(rules) == 0 {
+		return false
+	}
+
+	for _, logType := range logTypes {
+		ignored := false
+		for _, rule := range rules {
+			if (len(rule.LogType) == 0 || rule.LogType == logType) && rule.matches(subject) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			return false
+		}
+	}
+	return true
+}
+
+// markMessageIgnored remembers ignored messages without their content, so their edits and deletions are not reported
+// as cache misses.
+func (m *Module) markMessageIgnored(messageID string, policy messageCachePolicy) {
+	err := m.putCachedMessage(messageID, &CachedMessage{Ignored: true}, policy)
+	if err != nil {
+		m.logger.Warn("Error storing ignored message marker in cache", zap.Error(err))
+	}
+}
+
+func (target IgnoreTargetType) formatTarget(targetID string) string {
+	switch target {
+	case IgnoreTargetChannel, IgnoreTargetCategory:
+		return "<#" + targetID + ">"
+	case IgnoreTargetRole:
+		return "<@&" + targetID + ">"
+	case IgnoreTargetUser:
+		return "<@" + targetID + ">"
+	case IgnoreTargetBots:
+		return "All bots"
+	case IgnoreTargetWebhooks:
+		return "All webhooks"
+	}
+	return targetID
+}
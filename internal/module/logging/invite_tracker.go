@@ -0,0 +1,248 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// invite snapshots are refreshed on joins, the ttl only cleans up guilds the bot has left
+const inviteSnapshotTTL = time.Hour * 24 * 30
+
+func (m *Module) registerInviteListeners() {
+	for _, guild := range m.getStateGuilds() {
+		go m.refreshInviteSnapshot(guild.ID)
+	}
+
+	// invites could have been used or changed while the bot was offline
+	m.discord.AddHandler(func(_ *discordgo.Session, guildCreate *discordgo.GuildCreate) {
+		m.refreshInviteSnapshot(guildCreate.ID)
+	})
+	m.discord.AddHandler(m.handleInviteCreate)
+	m.discord.AddHandler(m.handleInviteDelete)
+}
+
+func inviteSnapshotKey(guildID string) string {
+	return "discord-invites:" + guildID
+}
+
+func newCachedInvite(invite *discordgo.Invite) CachedInvite {
+	cachedInvite := CachedInvite{
+		Code:            invite.Code,
+		Uses:            invite.Uses,
+		MaxUses:         invite.MaxUses,
+		InviterFullName: "Unknown",
+	}
+	if invite.Channel != nil {
+		cachedInvite.ChannelID = invite.Channel.ID
+	}
+	if invite.Inviter != nil {
+		cachedInvite.InviterID = invite.Inviter.ID
+		cachedInvite.InviterFullName = invite.Inviter.String()
+	}
+	return cachedInvite
+}
+
+// getInviteMutex returns the lock for the invite snapshot of the guild, so joins of different guilds don't wait for
+// each others invite requests.
+func (m *Module) getInviteMutex(guildID string) *sync.Mutex {
+	inviteMutex, _ := m.inviteMutexes.LoadOrStore(guildID, &sync.Mutex{})
+	return inviteMutex.(*sync.Mutex)
+}
+
+func (m *Module) refreshInviteSnapshot(guildID string) {
+	inviteMutex := m.getInviteMutex(guildID)
+	inviteMutex.Lock()
+	defer inviteMutex.Unlock()
+
+	invites, err := m.fetchGuildInvites(guildID)
+	if err != nil {
+		m.logger.Debug("Error fetching guild invites", zap.String("guild", guildID), zap.Error(err))
+		return
+	}
+	m.storeInviteSnapshot(guildID, invites)
+}
+
+// fetchGuildInvites returns all invites of the guild including its vanity url, which is not part of the invite list.
+func (m *Module) fetchGuildInvites(guildID string) ([]CachedInvite, error) {
+	guildInvites, err := m.discord.GuildInvites(guildID)
+	if err != nil {
+		return nil, err
+	}
+	var invites []CachedInvite
+	for _, invite := range guildInvites {
+		invites = append(invites, newCachedInvite(invite))
+	}
+
+	guild, err := m.discord.State.Guild(guildID)
+	if err != nil || len(guild.VanityURLCode) == 0 {
+		return invites, nil
+	}
+	body, err := m.discord.RequestWithBucketID("GET", discordgo.EndpointGuild(guildID)+"/vanity-url", nil, discordgo.EndpointGuild(guildID))
+	if err != nil {
+		m.logger.Debug("Error fetching guild vanity url", zap.String("guild", guildID), zap.Error(err))
+		return invites, nil
+	}
+	vanityInvite := discordgo.Invite{}
+	err = json.Unmarshal(body, &vanityInvite)
+	if err != nil || len(vanityInvite.Code) == 0 {
+		return invites, nil
+	}
+	return append(invites, CachedInvite{Code: vanityInvite.Code, Uses: vanityInvite.Uses, InviterFullName: "Vanity URL"}), nil
+}
+
+func (m *Module) loadInviteSnapshot(guildID string) (map[string]CachedInvite, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	values, err := m.cache.HGetAll(ctx, inviteSnapshotKey(guildID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(map[string]CachedInvite)
+	for code, value := range values {
+		cachedInvite := CachedInvite{}
+		err = cachedInvite.UnmarshalBinary([]byte(value))
+		if err != nil {
+			continue
+		}
+		snapshot[code] = cachedInvite
+	}
+	return snapshot, nil
+}
+
+func (m *Module) storeInviteSnapshot(guildID string, invites []CachedInvite) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	key := inviteSnapshotKey(guildID)
+	pipe := m.cache.TxPipeline()
+	pipe.Del(ctx, key)
+	for _, invite := range invites {
+		cachedInvite := invite
+		pipe.HSet(ctx, key, invite.Code, &cachedInvite)
+	}
+	pipe.Expire(ctx, key, inviteSnapshotTTL)
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		m.logger.Warn("Error storing invite snapshot in cache", zap.String("guild", guildID), zap.Error(err))
+	}
+}
+
+// findUsedInvite compares the current invite uses to the last snapshot to determine which invite a new member used.
+func (m *Module) findUsedInvite(guildID string) *CachedInvite {
+	inviteMutex := m.getInviteMutex(guildID)
+	inviteMutex.Lock()
+	defer inviteMutex.Unlock()
+
+	oldSnapshot, err := m.loadInviteSnapshot(guildID)
+	if err != nil {
+		m.logger.Warn("Error loading invite snapshot from cache", zap.String("guild", guildID), zap.Error(err))
+	}
+
+	invites, err := m.fetchGuildInvites(guildID)
+	if err != nil {
+		m.logger.Debug("Error fetching guild invites", zap.String("guild", guildID), zap.Error(err))
+		return nil
+	}
+	m.storeInviteSnapshot(guildID, invites)
+
+	if len(oldSnapshot) == 0 {
+		return nil
+	}
+
+	var candidates []CachedInvite
+	currentCodes := make(map[string]bool)
+	for _, invite := range invites {
+		currentCodes[invite.Code] = true
+		if oldInvite, ok := oldSnapshot[invite.Code]; ok && invite.Uses > oldInvite.Uses {
+			candidates = append(candidates, invite)
+		}
+	}
+	if len(candidates) == 0 {
+		// invites are deleted once they reach their max uses
+		for code, oldInvite := range oldSnapshot {
+			if !currentCodes[code] && oldInvite.MaxUses > 0 && oldInvite.Uses == oldInvite.MaxUses-1 {
+				oldInvite.Uses++
+				candidates = append(candidates, oldInvite)
+			}
+		}
+	}
+
+	// multiple candidates happen on simultaneous joins or joins while the bot was offline
+	if len(candidates) != 1 {
+		return nil
+	}
+	return &candidates[0]
+}
+
+func (m *Module) handleInviteCreate(_ *discordgo.Session, inviteCreate *discordgo.InviteCreate) {
+	cachedInvite := newCachedInvite(inviteCreate.Invite)
+	cachedInvite.ChannelID = inviteCreate.ChannelID
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	inviteMutex := m.getInviteMutex(inviteCreate.GuildID)
+	inviteMutex.Lock()
+	err := m.cache.HSet(ctx, inviteSnapshotKey(inviteCreate.GuildID), cachedInvite.Code, &cachedInvite).Err()
+	inviteMutex.Unlock()
+	if err != nil {
+		m.logger.Warn("Error storing invite in cache", zap.String("guild", inviteCreate.GuildID), zap.Error(err))
+	}
+
+	maxUses := "Unlimited"
+	if inviteCreate.MaxUses > 0 {
+		maxUses = strconv.Itoa(inviteCreate.MaxUses)
+	}
+	maxAge := "Never"
+	if inviteCreate.MaxAge > 0 {
+		maxAge = formatDuration(time.Duration(inviteCreate.MaxAge) * time.Second)
+	}
+
+	m.sendLogToDiscord(inviteCreate.GuildID, InviteCreate, map[string]string{
+		"invite_code":       cachedInvite.Code,
+		"channel_id":        inviteCreate.ChannelID,
+		"inviter_id":        cachedInvite.InviterID,
+		"inviter_full_name": cachedInvite.InviterFullName,
+		"max_uses":          maxUses,
+		"max_age":           maxAge,
+		"temporary":         strconv.FormatBool(inviteCreate.Temporary),
+	})
+}
+
+func (m *Module) handleInviteDelete(_ *discordgo.Session, inviteDelete *discordgo.InviteDelete) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cachedInvite := CachedInvite{Code: inviteDelete.Code, InviterFullName: "Unknown"}
+
+	inviteMutex := m.getInviteMutex(inviteDelete.GuildID)
+	inviteMutex.Lock()
+	err := m.cache.HGet(ctx, inviteSnapshotKey(inviteDelete.GuildID), inviteDelete.Code).Scan(&cachedInvite)
+	if err != nil {
+		m.logger.Debug("Deleted invite was not found in cache", zap.String("guild", inviteDelete.GuildID), zap.String("invite", inviteDelete.Code), zap.Error(err))
+	}
+	// keep exhausted invites in the snapshot, so the join that used them up can still be attributed
+	if cachedInvite.MaxUses == 0 || cachedInvite.Uses < cachedInvite.MaxUses-1 {
+		err = m.cache.HDel(ctx, inviteSnapshotKey(inviteDelete.GuildID), inviteDelete.Code).Err()
+		if err != nil {
+			m.logger.Warn("Error removing invite from cache", zap.String("guild", inviteDelete.GuildID), zap.Error(err))
+		}
+	}
+	inviteMutex.Unlock()
+
+	m.sendLogToDiscord(inviteDelete.GuildID, InviteDelete, map[string]string{
+		"invite_code":       inviteDelete.Code,
+		"channel_id":        inviteDelete.ChannelID,
+		"inviter_id":        cachedInvite.InviterID,
+		"inviter_full_name": cachedInvite.InviterFullName,
+		"invite_uses":       strconv.Itoa(cachedInvite.Uses),
+	})
+}
@@ -1,74 +1,396 @@
 package logging
 
 import (
+	"bytes"
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/templating"
+	"github.com/yannismate/gowlbot/internal/util"
 	"go.uber.org/zap"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
 )
 
+const (
+	textContentInlineLength   = 1000
+	textContentFollowUpLength = 1000
+	embedDescriptionMaxLength = 4096
+	embedFieldValueMaxLength  = 1024
+	embedContentMaxFields     = 4
+)
+
+var (
+	logTypeEmbedColors = map[LogType]int{
+		MessageEdit:          util.EmbedColorWarn,
+		MessageDelete:        util.EmbedColorError,
+		MessageBulkDelete:    util.EmbedColorError,
+		MemberJoin:           util.EmbedColorOK,
+		MemberLeave:          util.EmbedColorWarn,
+		MemberKick:           util.EmbedColorError,
+		MemberRoleChange:     util.EmbedColorInfo,
+		GuildBanAdd:          util.EmbedColorError,
+		GuildBanRemove:       util.EmbedColorOK,
+		ChannelCreate:        util.EmbedColorOK,
+		ChannelDelete:        util.EmbedColorError,
+		ChannelUpdate:        util.EmbedColorInfo,
+		RoleCreate:           util.EmbedColorOK,
+		RoleDelete:           util.EmbedColorError,
+		RoleUpdate:           util.EmbedColorInfo,
+		MemberNicknameChange: util.EmbedColorInfo,
+		UserProfileChange:    util.EmbedColorInfo,
+		VoiceJoin:            util.EmbedColorOK,
+		VoiceLeave:           util.EmbedColorWarn,
+		VoiceMove:            util.EmbedColorInfo,
+		VoiceStateChange:     util.EmbedColorInfo,
+		InviteCreate:         util.EmbedColorOK,
+		InviteDelete:         util.EmbedColorWarn,
+		MemberTimeoutAdd:     util.EmbedColorError,
+		MemberTimeoutRemove:  util.EmbedColorOK,
+		GhostPing:            util.EmbedColorWarn,
+	}
+	// content placeholders are split across messages in text mode and moved into their own fields in embed mode
+	contentPlaceholders = []contentPlaceholder{
+		{Key: "previous_content", Name: "Content", Escape: true},
+		{Key: "new_content", Name: "New Content", Escape: true},
+		{Key: "content_diff", Name: "Changes", Escape: true, Language: "ansi"},
+		{Key: "content_diff_plain", Name: "Changes", Escape: true},
+		{Key: "embeds", Name: "Embeds", Escape: true},
+		{Key: "attachments", Name: "Attachments"},
+		{Key: "removed_attachments", Name: "Removed Attachments"},
+		{Key: "changes", Name: "Changes"},
+	}
+)
+
+// logNotes are shown even if the format does not use them, formats saved before they existed would hide them otherwise
+var logNotes = []logNote{
+	{Key: "redactions", Name: "Redactions", Text: func(value string) string { return value + " redacted" }},
+	{Key: "kicked_by", Name: "Kicked By", Text: func(value string) string { return "kicked by **" + value + "**" }},
+}
+
+type logNote struct {
+	Key  string
+	Name string
+	Text func(value string) string
+}
+
+type contentPlaceholder struct {
+	Key      string
+	Name     string
+	Escape   bool
+	Language string
+}
+
+func (cp contentPlaceholder) substring(value string, start int, end int) string {
+	if cp.Language == "ansi" {
+		return substringANSI(value, start, end)
+	}
+	return substringUTF8(value, start, end)
+}
+
+func (cp contentPlaceholder) escape(value string) string {
+	if !cp.Escape {
+		return value
+	}
+	if len(cp.Language) > 0 {
+		return escapeDiscordStringWithLanguage(value, cp.Language)
+	}
+	return escapeDiscordString(value)
+}
+
 func (m *Module) sendLogToDiscord(guildID string, logType LogType, data map[string]string) {
+	m.sendLogWithFilesToDiscord(guildID, logType, data, nil)
+}
 
-	data["time"] = strconv.FormatInt(time.Now().UnixMilli()/1000, 10)
+func (m *Module) sendLogWithFilesToDiscord(guildID string, logType LogType, data map[string]string, files []*discordgo.File) {
 
-	logSettings := GuildLoggingSetting{}
+	data["time"] = strconv.FormatInt(time.Now().UnixMilli()/1000, 10)
 
-	result := m.db.Where(&GuildLoggingSetting{GuildID: guildID, LogType: logType}).First(&logSettings)
+	if m.isIgnored(guildID, m.newIgnoreSubjectFromData(guildID, data), logType) {
+		return
+	}
 
-	if result.Error != nil || result.RowsAffected == 0 || !logSettings.Enabled || len(logSettings.LoggingChannelID) == 0 {
+	destinations := m.getEnabledDestinations(guildID, logType)
+	if len(destinations) == 0 {
 		return
 	}
 
-	m.logger.Debug("Logging Event",
-		zap.Any("guild", guildID),
-		zap.Any("channel", logSettings.LoggingChannelID),
-		zap.Any("logType", logType),
-		zap.Any("data", data),
-	)
+	m.redactLogData(guildID, data)
+	m.archiveLogEvent(guildID, logType, data)
 
-	var secondMessageContent string
+	// file readers can only be consumed once, so they have to be buffered for multiple destinations
+	var fileContents [][]byte
+	if len(destinations) > 1 {
+		for _, file := range files {
+			content, err := io.ReadAll(file.Reader)
+			if err != nil {
+				m.logger.Error("Error buffering log file", zap.String("guild", guildID), zap.String("file", file.Name), zap.Error(err))
+			}
+			fileContents = append(fileContents, content)
+		}
+	}
 
-	var replaceList []string
-	for key, value := range data {
-		if key == "previous_content" && strings.Contains(logSettings.Format, "previous_content") {
-			if utf8.RuneCountInString(value) > 1000 {
-				fullValue := value
-				value = escapeDiscordString(substringUTF8(fullValue, 0, 1000))
-				secondMessageContent = escapeDiscordString(substringUTF8(fullValue, 1000, 2000))
-			} else {
-				value = escapeDiscordString(value)
+	for _, destination := range destinations {
+		m.logger.Debug("Logging Event",
+			zap.Any("guild", guildID),
+			zap.Any("channel", destination.ChannelID),
+			zap.Any("logType", logType),
+			zap.Any("outputMode", destination.OutputMode),
+			zap.Any("data", data),
+		)
+
+		destinationFiles := files
+		if fileContents != nil {
+			destinationFiles = make([]*discordgo.File, len(files))
+			for i, file := range files {
+				destinationFiles[i] = &discordgo.File{Name: file.Name, ContentType: file.ContentType, Reader: bytes.NewReader(fileContents[i])}
 			}
 		}
-		replaceList = append(replaceList, "{"+key+"}", value)
+
+		if destination.OutputMode == OutputModeEmbed {
+			m.sendEmbedLog(destination, data, destinationFiles)
+		} else {
+			m.sendTextLog(destination, data, destinationFiles)
+		}
 	}
-	replacer := strings.NewReplacer(replaceList...)
+}
 
-	resultString := replacer.Replace(logSettings.Format)
+func (m *Module) getEnabledDestinations(guildID string, logType LogType) []GuildLoggingDestination {
+	var destinations []GuildLoggingDestination
+
+	result := m.db.Where(&GuildLoggingDestination{GuildID: guildID, LogType: logType, Enabled: true}).Find(&destinations)
+	if result.Error != nil {
+		m.logger.Error("Error fetching logging destinations from db", zap.String("guild", guildID), zap.Any("logType", logType), zap.Error(result.Error))
+		return nil
+	}
 
-	_, err := m.discord.ChannelMessageSend(logSettings.LoggingChannelID, resultString)
+	if fallbackLogType, ok := logTypeFallbacks[logType]; ok && len(destinations) == 0 && !m.hasDestinations(guildID, logType) {
+		destinations = m.getEnabledDestinations(guildID, fallbackLogType)
+		for i := range destinations {
+			destinations[i].LogType = logType
+			destinations[i].Format = defaultLoggingFormats[logType]
+		}
+	}
+
+	return destinations
+}
+
+func (m *Module) isLoggingEnabled(guildID string, logType LogType) bool {
+	var count int64
+
+	result := m.db.Model(&GuildLoggingDestination{}).Where(&GuildLoggingDestination{GuildID: guildID, LogType: logType, Enabled: true}).Count(&count)
+	if result.Error != nil {
+		return false
+	}
+
+	if fallbackLogType, ok := logTypeFallbacks[logType]; ok && count == 0 && !m.hasDestinations(guildID, logType) {
+		return m.isLoggingEnabled(guildID, fallbackLogType)
+	}
+	return count > 0
+}
+
+// hasDestinations reports whether the log type was configured, including disabled destinations.
+func (m *Module) hasDestinations(guildID string, logType LogType) bool {
+	var count int64
+
+	result := m.db.Model(&GuildLoggingDestination{}).Where(&GuildLoggingDestination{GuildID: guildID, LogType: logType}).Count(&count)
+
+	return result.Error != nil || count > 0
+}
+
+// parseDestinationFormat reports invalid formats to the destination, formats saved before validation existed may not
+// parse anymore.
+func (m *Module) parseDestinationFormat(destination GuildLoggingDestination) (*templating.Template, bool) {
+	tmpl, err := templating.Parse(destination.Format)
 	if err != nil {
-		m.logger.Error("Error sending log message to Discord", zap.Any("guild", guildID), zap.Any("channel", logSettings.LoggingChannelID), zap.Error(err))
+		m.logger.Warn("Invalid logging format", zap.String("guild", destination.GuildID), zap.Any("logType", destination.LogType), zap.Error(err))
+		m.sendErrorLogToDestination(destination, "The format for "+destination.LogType.ToReadableString()+" logs is invalid: "+err.Error())
+		return nil, false
+	}
+	return tmpl, true
+}
+
+func (m *Module) sendTextLog(destination GuildLoggingDestination, data map[string]string, files []*discordgo.File) {
+	tmpl, ok := m.parseDestinationFormat(destination)
+	if !ok {
 		return
 	}
 
-	if len(secondMessageContent) > 0 {
-		_, err = m.discord.ChannelMessageSend(logSettings.LoggingChannelID, secondMessageContent)
+	messages := renderTextLog(tmpl, data)
+
+	for i, content := range messages {
+		// logs name members and roles without pinging them
+		msg := discordgo.MessageSend{Content: content, AllowedMentions: &discordgo.MessageAllowedMentions{}}
+		if i == len(messages)-1 {
+			msg.Files = files
+		}
+
+		_, err := m.discord.ChannelMessageSendComplex(destination.ChannelID, &msg)
 		if err != nil {
-			m.logger.Error("Error sending second log message to Discord", zap.Any("guild", guildID), zap.Any("channel", logSettings.LoggingChannelID), zap.Error(err))
+			m.logger.Error("Error sending log message to Discord", zap.Any("guild", destination.GuildID), zap.Any("channel", destination.ChannelID), zap.Int("part", i), zap.Error(err))
+			return
 		}
 	}
 }
 
-func (m *Module) sendErrorLogToDiscord(guildID string, logType LogType, message string) {
+// renderTextLog renders the log message followed by the messages containing content that did not fit into it.
+func renderTextLog(tmpl *templating.Template, data map[string]string) []string {
+	var usedPlaceholders []contentPlaceholder
+	for _, placeholder := range contentPlaceholders {
+		if _, ok := data[placeholder.Key]; ok && placeholder.Escape && tmpl.UsesVariable(placeholder.Key) {
+			usedPlaceholders = append(usedPlaceholders, placeholder)
+		}
+	}
 
-	logSettings := GuildLoggingSetting{}
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		values[key] = value
+	}
 
-	result := m.db.Where(&GuildLoggingSetting{GuildID: guildID, LogType: logType}).First(&logSettings)
+	// content that does not fit into the first message is continued in follow-up messages. Filters of the format are
+	// applied to the raw content, it is escaped afterwards.
+	var followUpMessages []string
+	escapers := make(map[string]templating.Escaper)
+	if len(usedPlaceholders) > 0 {
+		inlineLength := textContentInlineLength / len(usedPlaceholders)
+		for _, placeholder := range usedPlaceholders {
+			escapers[placeholder.Key] = placeholder.escape
+			value := data[placeholder.Key]
+			if utf8.RuneCountInString(value) <= inlineLength {
+				continue
+			}
+			values[placeholder.Key] = placeholder.substring(value, 0, inlineLength)
+			followUp := placeholder.escape(placeholder.substring(value, inlineLength, inlineLength+textContentFollowUpLength))
+			if len(usedPlaceholders) > 1 {
+				followUp = "**" + placeholder.Name + " (continued)**\n" + followUp
+			}
+			followUpMessages = append(followUpMessages, followUp)
+		}
+	}
+
+	content := tmpl.ExecuteEscaped(values, escapers)
+	for _, note := range logNotes {
+		if value := data[note.Key]; len(value) > 0 && !tmpl.UsesVariable(note.Key) {
+			content += " *(" + note.Text(value) + ")*"
+		}
+	}
 
-	if result.Error != nil || result.RowsAffected == 0 {
+	return append([]string{content}, followUpMessages...)
+}
+
+func (m *Module) sendEmbedLog(destination GuildLoggingDestination, data map[string]string, files []*discordgo.File) {
+	tmpl, ok := m.parseDestinationFormat(destination)
+	if !ok {
 		return
 	}
+	embed := buildLogEmbed(destination.LogType, tmpl, data)
+
+	_, err := m.discord.ChannelMessageSendComplex(destination.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		Files:           files,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		m.logger.Error("Error sending log embed to Discord", zap.Any("guild", destination.GuildID), zap.Any("channel", destination.ChannelID), zap.Error(err))
+	}
+}
+
+func buildLogEmbed(logType LogType, tmpl *templating.Template, data map[string]string) *discordgo.MessageEmbed {
+	var fields []*discordgo.MessageEmbedField
+
+	if channelID, ok := data["channel_id"]; ok && len(channelID) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Channel", Value: "<#" + channelID + ">", Inline: true})
+	}
+	if authorID, ok := data["author_id"]; ok && len(authorID) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Author", Value: "<@" + authorID + ">", Inline: true})
+	}
+	if memberID, ok := data["member_id"]; ok && len(memberID) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Member", Value: "<@" + memberID + ">", Inline: true})
+	}
+	if moderatorID, ok := data["moderator_id"]; ok && len(moderatorID) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Moderator", Value: "<@" + moderatorID + ">", Inline: true})
+		if reason, ok := data["reason"]; ok {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: "Reason", Value: reason})
+		}
+	}
+	for _, note := range logNotes {
+		if value := data[note.Key]; len(value) > 0 && !tmpl.UsesVariable(note.Key) {
+			fields = append(fields, &discordgo.MessageEmbedField{Name: note.Name, Value: value, Inline: true})
+		}
+	}
+
+	// content is shown in fields, conditions of the format still see the raw value
+	escapers := make(map[string]templating.Escaper)
+	for _, placeholder := range contentPlaceholders {
+		value, ok := data[placeholder.Key]
+		if !ok || !tmpl.UsesVariable(placeholder.Key) {
+			continue
+		}
+		reference := "*(see " + placeholder.Name + " below)*"
+		escapers[placeholder.Key] = func(string) string { return reference }
+		fields = append(fields, splitIntoEmbedFields(placeholder, value)...)
+	}
+
+	description := tmpl.ExecuteEscaped(data, escapers)
+	if utf8.RuneCountInString(description) > embedDescriptionMaxLength {
+		description = substringUTF8(description, 0, embedDescriptionMaxLength-1) + "…"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Type:        discordgo.EmbedTypeRich,
+		Title:       logType.ToReadableString(),
+		Description: description,
+		Fields:      fields,
+		Color:       logTypeEmbedColors[logType],
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "gowlbot " + util.GetVersionString(),
+		},
+	}
+
+	if avatarURL, ok := data["new_avatar_url"]; ok && len(avatarURL) > 0 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: avatarURL}
+	}
+
+	if authorName, ok := data["author_full_name"]; ok {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: authorName}
+	} else if memberName, ok := data["member_full_name"]; ok {
+		embed.Author = &discordgo.MessageEmbedAuthor{Name: memberName}
+	}
+
+	return embed
+}
+
+func splitIntoEmbedFields(placeholder contentPlaceholder, value string) []*discordgo.MessageEmbedField {
+	if len(value) == 0 {
+		return []*discordgo.MessageEmbedField{{Name: placeholder.Name, Value: "*(empty)*"}}
+	}
+
+	// leave room for the code block markers and zero width spaces added by escaping
+	chunkLength := embedFieldValueMaxLength - 64
+
+	var fields []*discordgo.MessageEmbedField
+	runeCount := utf8.RuneCountInString(value)
+	for start := 0; start < runeCount && len(fields) < embedContentMaxFields; start += chunkLength {
+		fieldName := placeholder.Name
+		if start > 0 {
+			fieldName = placeholder.Name + " (continued)"
+		}
+		chunk := placeholder.escape(placeholder.substring(value, start, start+chunkLength))
+		if utf8.RuneCountInString(chunk) > embedFieldValueMaxLength {
+			if placeholder.Escape {
+				chunk = substringUTF8(chunk, 0, embedFieldValueMaxLength-4) + "…```"
+			} else {
+				chunk = substringUTF8(chunk, 0, embedFieldValueMaxLength-1) + "…"
+			}
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: fieldName, Value: chunk})
+	}
+	return fields
+}
+
+func (m *Module) sendErrorLogToDiscord(guildID string, logType LogType, message string) {
 
 	m.logger.Debug("Logging Error Event",
 		zap.Any("guild", guildID),
@@ -76,10 +398,33 @@ func (m *Module) sendErrorLogToDiscord(guildID string, logType LogType, message
 		zap.Any("message", message),
 	)
 
-	timestamp := strconv.FormatInt(time.Now().UnixMilli()/1000, 10)
-	_, err := m.discord.ChannelMessageSend(logSettings.LoggingChannelID, "<t:"+timestamp+"> Internal Error: "+message)
+	for _, destination := range m.getEnabledDestinations(guildID, logType) {
+		m.sendErrorLogToDestination(destination, message)
+	}
+}
+
+func (m *Module) sendErrorLogToDestination(destination GuildLoggingDestination, message string) {
+	var err error
+	if destination.OutputMode == OutputModeEmbed {
+		_, err = m.discord.ChannelMessageSendEmbed(destination.ChannelID, &discordgo.MessageEmbed{
+			Type:        discordgo.EmbedTypeRich,
+			Title:       "Internal Error",
+			Description: message,
+			Color:       util.EmbedColorError,
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: "gowlbot " + util.GetVersionString(),
+			},
+		})
+	} else {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli()/1000, 10)
+		_, err = m.discord.ChannelMessageSendComplex(destination.ChannelID, &discordgo.MessageSend{
+			Content:         "<t:" + timestamp + "> Internal Error: " + message,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+	}
 	if err != nil {
-		m.logger.Error("Error sending error log message to Discord", zap.Any("guild", guildID), zap.Any("channel", logSettings.LoggingChannelID), zap.Error(err))
+		m.logger.Error("Error sending error log message to Discord", zap.Any("guild", destination.GuildID), zap.Any("channel", destination.ChannelID), zap.Error(err))
 	}
 }
 
@@ -98,6 +443,10 @@ func substringUTF8(s string, start int, end int) string {
 	return s[startStrIdx:]
 }
 
+func escapeDiscordStringWithLanguage(content string, language string) string {
+	return "```" + language + "\n" + strings.ReplaceAll(content, "`", "`\u200B") + "```"
+}
+
 func escapeDiscordString(content string) string {
 	return "```" + strings.ReplaceAll(content, "`", "`\u200B") + "```"
 }
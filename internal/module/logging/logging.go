@@ -3,21 +3,61 @@ package logging
 import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v9"
+	"github.com/yannismate/gowlbot/internal/cache"
 	"github.com/yannismate/gowlbot/internal/config"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"sync"
+	"time"
 )
 
 type Module struct {
-	config  *config.OwlBotConfig
-	discord *discordgo.Session
-	db      *gorm.DB
-	cache   *redis.Client
-	logger  *zap.Logger
+	config      *config.OwlBotConfig
+	discord     *discordgo.Session
+	db          *gorm.DB
+	cache       *redis.Client
+	messages    cache.MessageStore
+	attachments *cache.AttachmentStore
+	logger      *zap.Logger
+
+	auditLogMutex            sync.Mutex
+	cacheWarmupMutex         sync.Mutex
+	inviteMutexes            sync.Map
+	messageDeleteEntryCounts map[string]int
+
+	channelSnapshots *snapshotStore[channelSnapshot]
+	roleSnapshots    *snapshotStore[roleSnapshot]
+	voiceSessions    *snapshotStore[time.Time]
+	timeoutTimers    *snapshotStore[*time.Timer]
+	warmedUpGuilds   *snapshotStore[bool]
+
+	// guild settings are read for every event, they are cached until a command changes them
+	guildConfigs      *snapshotStore[GuildLoggingConfig]
+	ignoreRules       *snapshotStore[[]LoggingIgnoreRule]
+	cachePolicies     *snapshotStore[messageCachePolicy]
+	redactionRuleSets *snapshotStore[redactionRuleSet]
 }
 
-func ProvideLoggingModule(config *config.OwlBotConfig, discord *discordgo.Session, db *gorm.DB, cache *redis.Client, logger *zap.Logger) *Module {
-	return &Module{config: config, discord: discord, db: db, cache: cache, logger: logger}
+func ProvideLoggingModule(config *config.OwlBotConfig, discord *discordgo.Session, db *gorm.DB, redisClient *redis.Client, messages cache.MessageStore, attachments *cache.AttachmentStore, logger *zap.Logger) *Module {
+	return &Module{
+		config:                   config,
+		discord:                  discord,
+		db:                       db,
+		cache:                    redisClient,
+		messages:                 messages,
+		attachments:              attachments,
+		logger:                   logger,
+		messageDeleteEntryCounts: make(map[string]int),
+		channelSnapshots:         newSnapshotStore[channelSnapshot](),
+		roleSnapshots:            newSnapshotStore[roleSnapshot](),
+		voiceSessions:            newSnapshotStore[time.Time](),
+		timeoutTimers:            newSnapshotStore[*time.Timer](),
+		warmedUpGuilds:           newSnapshotStore[bool](),
+		guildConfigs:             newSnapshotStore[GuildLoggingConfig](),
+		ignoreRules:              newSnapshotStore[[]LoggingIgnoreRule](),
+		cachePolicies:            newSnapshotStore[messageCachePolicy](),
+		redactionRuleSets:        newSnapshotStore[redactionRuleSet](),
+	}
 }
 
 func (m *Module) Name() string {
@@ -25,15 +65,34 @@ func (m *Module) Name() string {
 }
 
 func (m *Module) Start() error {
-	err := m.db.AutoMigrate(&GuildLoggingSetting{})
+	err := m.db.AutoMigrate(&GuildLoggingDestination{}, &GuildLoggingConfig{}, &LoggingIgnoreRule{}, &LogRecord{}, &MemberSnapshot{}, &GuildCacheChannel{}, &RedactionRule{})
 	if err != nil {
 		m.logger.Error("Could not prepare database for logging module", zap.Error(err))
 		return err
 	}
+	err = m.migrateLegacyLoggingSettings()
+	if err != nil {
+		m.logger.Error("Could not migrate legacy logging settings", zap.Error(err))
+		return err
+	}
 	m.registerMessageListeners()
 	m.registerMemberJoinLeaveListeners()
 	m.registerMemberRoleListeners()
+	m.registerMemberProfileListeners()
 	m.registerMemberBanListeners()
+	m.registerChannelListeners()
+	m.registerRoleListeners()
+	m.registerVoiceListeners()
+	m.registerInviteListeners()
 	m.registerSlashCommandListeners()
+	m.registerCacheWarmup()
+	m.startArchiveCleanup()
 	return nil
 }
+
+// getStateGuilds copies the guild list of the state, since the gateway appends to it while the modules start.
+func (m *Module) getStateGuilds() []*discordgo.Guild {
+	m.discord.State.RLock()
+	defer m.discord.State.RUnlock()
+	return append([]*discordgo.Guild(nil), m.discord.State.Guilds...)
+}
@@ -10,15 +10,29 @@ func (m *Module) registerMemberBanListeners() {
 }
 
 func (m *Module) handleGuildBanAdd(_ *discordgo.Session, banAdd *discordgo.GuildBanAdd) {
-	m.sendLogToDiscord(banAdd.GuildID, GuildBanAdd, map[string]string{
+	if !m.isLoggingEnabled(banAdd.GuildID, GuildBanAdd) {
+		return
+	}
+
+	data := map[string]string{
 		"member_id":        banAdd.User.ID,
 		"member_full_name": banAdd.User.String(),
-	})
+	}
+	m.findAuditLogAttribution(banAdd.GuildID, discordgo.AuditLogActionMemberBanAdd, banAdd.User.ID).addToLogData(data)
+
+	m.sendLogToDiscord(banAdd.GuildID, GuildBanAdd, data)
 }
 
 func (m *Module) handleGuildBanRemove(_ *discordgo.Session, banRemove *discordgo.GuildBanRemove) {
-	m.sendLogToDiscord(banRemove.GuildID, GuildBanRemove, map[string]string{
+	if !m.isLoggingEnabled(banRemove.GuildID, GuildBanRemove) {
+		return
+	}
+
+	data := map[string]string{
 		"member_id":        banRemove.User.ID,
 		"member_full_name": banRemove.User.String(),
-	})
+	}
+	m.findAuditLogAttribution(banRemove.GuildID, discordgo.AuditLogActionMemberBanRemove, banRemove.User.ID).addToLogData(data)
+
+	m.sendLogToDiscord(banRemove.GuildID, GuildBanRemove, data)
 }
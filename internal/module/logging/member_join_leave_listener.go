@@ -12,41 +12,68 @@ func (m *Module) registerMemberJoinLeaveListeners() {
 }
 
 func (m *Module) handleMemberJoin(_ *discordgo.Session, add *discordgo.GuildMemberAdd) {
+	if !m.isLoggingEnabled(add.GuildID, MemberJoin) {
+		return
+	}
+
+	memberCount := "Unknown"
 	guild, err := m.discord.State.Guild(add.GuildID)
 	if err != nil {
 		m.logger.Error("Error getting guild state to log member join", zap.String("guild", add.GuildID), zap.Error(err))
-		m.sendLogToDiscord(add.GuildID, MemberJoin, map[string]string{
-			"member_id":          add.User.ID,
-			"member_full_name":   add.User.String(),
-			"guild_member_count": "Unknown",
-		})
-		return
+	} else {
+		memberCount = strconv.Itoa(guild.MemberCount)
 	}
-	memberCount := strconv.Itoa(guild.MemberCount)
 
-	m.sendLogToDiscord(add.GuildID, MemberJoin, map[string]string{
+	data := map[string]string{
 		"member_id":          add.User.ID,
 		"member_full_name":   add.User.String(),
 		"guild_member_count": memberCount,
-	})
+		"invite_code":        "Unknown",
+		"invite_uses":        "Unknown",
+		"inviter_id":         "",
+		"inviter_full_name":  "Unknown",
+	}
+	if usedInvite := m.findUsedInvite(add.GuildID); usedInvite != nil {
+		data["invite_code"] = usedInvite.Code
+		data["invite_uses"] = strconv.Itoa(usedInvite.Uses)
+		data["inviter_id"] = usedInvite.InviterID
+		data["inviter_full_name"] = usedInvite.InviterFullName
+	}
+
+	m.sendLogToDiscord(add.GuildID, MemberJoin, data)
 }
 
 func (m *Module) handleMemberLeave(_ *discordgo.Session, remove *discordgo.GuildMemberRemove) {
+	memberCount := "Unknown"
 	guild, err := m.discord.State.Guild(remove.GuildID)
 	if err != nil {
 		m.logger.Error("Error getting guild state to log member leave", zap.String("guild", remove.GuildID), zap.Error(err))
-		m.sendLogToDiscord(remove.GuildID, MemberLeave, map[string]string{
-			"member_id":          remove.User.ID,
-			"member_full_name":   remove.User.String(),
-			"guild_member_count": "Unknown",
-		})
-		return
+	} else {
+		memberCount = strconv.Itoa(guild.MemberCount)
 	}
-	memberCount := strconv.Itoa(guild.MemberCount)
 
-	m.sendLogToDiscord(remove.GuildID, MemberLeave, map[string]string{
+	data := map[string]string{
 		"member_id":          remove.User.ID,
 		"member_full_name":   remove.User.String(),
 		"guild_member_count": memberCount,
-	})
+	}
+
+	kickLoggingEnabled := m.isLoggingEnabled(remove.GuildID, MemberKick)
+	if !kickLoggingEnabled && !m.isLoggingEnabled(remove.GuildID, MemberLeave) {
+		return
+	}
+
+	// kicks are only distinguishable from voluntary leaves through the audit log
+	attribution := m.findAuditLogAttribution(remove.GuildID, discordgo.AuditLogActionMemberKick, remove.User.ID)
+	if attribution != nil && kickLoggingEnabled {
+		attribution.addToLogData(data)
+		m.sendLogToDiscord(remove.GuildID, MemberKick, data)
+		return
+	}
+
+	data["kicked_by"] = ""
+	if attribution != nil {
+		data["kicked_by"] = attribution.ModeratorFullName
+	}
+	m.sendLogToDiscord(remove.GuildID, MemberLeave, data)
 }
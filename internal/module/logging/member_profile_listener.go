@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+func (m *Module) registerMemberProfileListeners() {
+	m.discord.AddHandler(m.handleMemberProfileUpdate)
+	m.discord.AddHandler(func(_ *discordgo.Session, add *discordgo.GuildMemberAdd) {
+		if m.isMemberSnapshotLoggingEnabled(add.GuildID) {
+			m.storeMemberSnapshot(newMemberSnapshot(add.Member))
+		}
+	})
+	m.discord.AddHandler(func(_ *discordgo.Session, remove *discordgo.GuildMemberRemove) {
+		m.cancelTimeoutExpiry(remove.GuildID, remove.User.ID)
+		m.deleteMemberSnapshot(remove.GuildID, remove.User.ID)
+	})
+
+	m.restoreTimeoutTimers()
+}
+
+// isMemberSnapshotLoggingEnabled reports whether any log type compares member snapshots, they are not persisted
+// otherwise.
+func (m *Module) isMemberSnapshotLoggingEnabled(guildID string) bool {
+	for _, logType := range []LogType{MemberNicknameChange, UserProfileChange, MemberTimeoutAdd, MemberTimeoutRemove} {
+		if m.isLoggingEnabled(guildID, logType) {
+			return true
+		}
+	}
+	return false
+}
+
+func newMemberSnapshot(member *discordgo.Member) MemberSnapshot {
+	return MemberSnapshot{
+		GuildID:      member.GuildID,
+		UserID:       member.User.ID,
+		Nickname:     member.Nick,
+		Username:     member.User.String(),
+		Avatar:       member.User.Avatar,
+		GuildAvatar:  member.Avatar,
+		TimeoutUntil: member.CommunicationDisabledUntil,
+	}
+}
+
+func (m *Module) handleMemberProfileUpdate(_ *discordgo.Session, memberUpdate *discordgo.GuildMemberUpdate) {
+	if memberUpdate.User == nil || !m.isMemberSnapshotLoggingEnabled(memberUpdate.GuildID) {
+		return
+	}
+	newSnapshot := newMemberSnapshot(memberUpdate.Member)
+
+	// the state cache is lost on restarts, so fall back to the persisted snapshot
+	var oldSnapshot MemberSnapshot
+	if memberUpdate.BeforeUpdate != nil && memberUpdate.BeforeUpdate.User != nil {
+		oldSnapshot = newMemberSnapshot(memberUpdate.BeforeUpdate)
+		oldSnapshot.GuildID = memberUpdate.GuildID
+	} else {
+		dbResult := m.db.Where(&MemberSnapshot{GuildID: memberUpdate.GuildID, UserID: memberUpdate.User.ID}).First(&oldSnapshot)
+		if dbResult.Error != nil {
+			if !errors.Is(dbResult.Error, gorm.ErrRecordNotFound) {
+				m.logger.Error("Error fetching member snapshot from db", zap.String("guild", memberUpdate.GuildID), zap.String("user", memberUpdate.User.ID), zap.Error(dbResult.Error))
+			}
+			m.storeMemberSnapshot(newSnapshot)
+			if newSnapshot.TimeoutUntil != nil && newSnapshot.TimeoutUntil.After(time.Now()) {
+				m.scheduleTimeoutExpiry(newSnapshot.GuildID, newSnapshot.UserID, newSnapshot.Username, *newSnapshot.TimeoutUntil)
+			}
+			return
+		}
+	}
+
+	if !oldSnapshot.sameProfile(newSnapshot) {
+		m.storeMemberSnapshot(newSnapshot)
+	}
+
+	m.logMemberTimeoutChange(memberUpdate, oldSnapshot.TimeoutUntil, newSnapshot.TimeoutUntil)
+
+	if oldSnapshot.Nickname != newSnapshot.Nickname && m.isLoggingEnabled(memberUpdate.GuildID, MemberNicknameChange) {
+		data := map[string]string{
+			"member_id":        memberUpdate.User.ID,
+			"member_full_name": memberUpdate.User.String(),
+			"old_nickname":     formatOptionalString(oldSnapshot.Nickname),
+			"new_nickname":     formatOptionalString(newSnapshot.Nickname),
+		}
+		m.findAuditLogAttribution(memberUpdate.GuildID, discordgo.AuditLogActionMemberUpdate, memberUpdate.User.ID).addToLogData(data)
+
+		m.sendLogToDiscord(memberUpdate.GuildID, MemberNicknameChange, data)
+	}
+
+	var changes []string
+	if oldSnapshot.Username != newSnapshot.Username {
+		changes = append(changes, "Username: `"+oldSnapshot.Username+"` → `"+newSnapshot.Username+"`")
+	}
+	if oldSnapshot.Avatar != newSnapshot.Avatar {
+		changes = append(changes, "Avatar changed")
+	}
+	if oldSnapshot.GuildAvatar != newSnapshot.GuildAvatar {
+		if len(newSnapshot.GuildAvatar) == 0 {
+			changes = append(changes, "Server avatar removed")
+		} else {
+			changes = append(changes, "Server avatar changed")
+		}
+	}
+
+	if len(changes) > 0 {
+		m.sendLogToDiscord(memberUpdate.GuildID, UserProfileChange, map[string]string{
+			"member_id":        memberUpdate.User.ID,
+			"member_full_name": memberUpdate.User.String(),
+			"old_username":     oldSnapshot.Username,
+			"new_username":     newSnapshot.Username,
+			"new_avatar_url":   memberUpdate.Member.AvatarURL("256"),
+			"changes":          strings.Join(changes, "\n"),
+		})
+	}
+}
+
+func (ms MemberSnapshot) sameProfile(other MemberSnapshot) bool {
+	sameTimeout := ms.TimeoutUntil == nil && other.TimeoutUntil == nil ||
+		ms.TimeoutUntil != nil && other.TimeoutUntil != nil && ms.TimeoutUntil.Equal(*other.TimeoutUntil)
+	return sameTimeout && ms.Nickname == other.Nickname && ms.Username == other.Username && ms.Avatar == other.Avatar &&
+		ms.GuildAvatar == other.GuildAvatar
+}
+
+func (m *Module) storeMemberSnapshot(snapshot MemberSnapshot) {
+	dbResult := m.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "guild_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"nickname", "username", "avatar", "guild_avatar", "timeout_until"}),
+	}).Create(&snapshot)
+	if dbResult.Error != nil {
+		m.logger.Error("Error storing member snapshot in db", zap.String("guild", snapshot.GuildID), zap.String("user", snapshot.UserID), zap.Error(dbResult.Error))
+	}
+}
+
+func (m *Module) deleteMemberSnapshot(guildID string, userID string) {
+	dbResult := m.db.Where(&MemberSnapshot{GuildID: guildID, UserID: userID}).Delete(&MemberSnapshot{})
+	if dbResult.Error != nil {
+		m.logger.Error("Error deleting member snapshot from db", zap.String("guild", guildID), zap.String("user", userID), zap.Error(dbResult.Error))
+	}
+}
@@ -12,6 +12,11 @@ func (m *Module) registerMemberRoleListeners() {
 func (m *Module) handleMemberUpdate(_ *discordgo.Session, memberUpdate *discordgo.GuildMemberUpdate) {
 	oldMember := memberUpdate.BeforeUpdate
 
+	// without a previous state there is nothing to compare against
+	if oldMember == nil || !m.isLoggingEnabled(memberUpdate.GuildID, MemberRoleChange) {
+		return
+	}
+
 	if added, removed, hasChanges := findRoleDifferences(oldMember.Roles, memberUpdate.Roles); hasChanges {
 		guild, err := m.discord.State.Guild(memberUpdate.GuildID)
 		var guildRoles []*discordgo.Role
@@ -38,13 +43,16 @@ func (m *Module) handleMemberUpdate(_ *discordgo.Session, memberUpdate *discordg
 			roleChanges = append(roleChanges, "-"+rr)
 		}
 
-		m.sendLogToDiscord(memberUpdate.GuildID, MemberRoleChange, map[string]string{
+		data := map[string]string{
 			"member_id":        memberUpdate.User.ID,
 			"member_full_name": memberUpdate.User.String(),
 			"old_roles":        strings.Join(oldRoleNames, ","),
 			"new_roles":        strings.Join(newRoleNames, ","),
 			"role_changes":     strings.Join(roleChanges, ","),
-		})
+		}
+		m.findAuditLogAttribution(memberUpdate.GuildID, discordgo.AuditLogActionMemberRoleUpdate, memberUpdate.User.ID).addToLogData(data)
+
+		m.sendLogToDiscord(memberUpdate.GuildID, MemberRoleChange, data)
 	}
 }
 
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+// discordgo has no constant for the timeout change of member updates
+const auditLogChangeKeyTimeout discordgo.AuditLogChangeKey = "communication_disabled_until"
+
+func (m *Module) restoreTimeoutTimers() {
+	var snapshots []MemberSnapshot
+	dbResult := m.db.Where("timeout_until IS NOT NULL").Find(&snapshots)
+	if dbResult.Error != nil {
+		m.logger.Error("Error fetching timed out members from db", zap.Error(dbResult.Error))
+		return
+	}
+
+	for _, snapshot := range snapshots {
+		// timeouts that ended while the bot was offline are logged right away, with their actual expiry
+		m.scheduleTimeoutExpiry(snapshot.GuildID, snapshot.UserID, snapshot.Username, *snapshot.TimeoutUntil)
+	}
+}
+
+func (m *Module) logMemberTimeoutChange(memberUpdate *discordgo.GuildMemberUpdate, oldUntil *time.Time, newUntil *time.Time) {
+	now := time.Now()
+	wasTimedOut := oldUntil != nil && oldUntil.After(now)
+	isTimedOut := newUntil != nil && newUntil.After(now)
+
+	data := map[string]string{
+		"member_id":        memberUpdate.User.ID,
+		"member_full_name": memberUpdate.User.String(),
+	}
+
+	if isTimedOut && (!wasTimedOut || !oldUntil.Equal(*newUntil)) {
+		m.scheduleTimeoutExpiry(memberUpdate.GuildID, memberUpdate.User.ID, memberUpdate.User.String(), *newUntil)
+
+		if !m.isLoggingEnabled(memberUpdate.GuildID, MemberTimeoutAdd) {
+			return
+		}
+		data["timeout_until"] = formatDiscordTimestamp(*newUntil)
+		m.findAuditLogAttributionForChange(memberUpdate.GuildID, discordgo.AuditLogActionMemberUpdate, memberUpdate.User.ID, auditLogChangeKeyTimeout).addToLogData(data)
+		m.sendLogToDiscord(memberUpdate.GuildID, MemberTimeoutAdd, data)
+	} else if wasTimedOut && !isTimedOut {
+		m.cancelTimeoutExpiry(memberUpdate.GuildID, memberUpdate.User.ID)
+
+		if !m.isLoggingEnabled(memberUpdate.GuildID, MemberTimeoutRemove) {
+			return
+		}
+		data["timeout_until"] = formatDiscordTimestamp(*oldUntil)
+		data["timeout_end_reason"] = "Removed"
+		m.findAuditLogAttributionForChange(memberUpdate.GuildID, discordgo.AuditLogActionMemberUpdate, memberUpdate.User.ID, auditLogChangeKeyTimeout).addToLogData(data)
+		m.sendLogToDiscord(memberUpdate.GuildID, MemberTimeoutRemove, data)
+	}
+}
+
+// scheduleTimeoutExpiry logs the end of a timeout, since Discord does not send an update when timeouts expire.
+func (m *Module) scheduleTimeoutExpiry(guildID string, userID string, userFullName string, until time.Time) {
+	key := guildID + ":" + userID
+	m.cancelTimeoutExpiry(guildID, userID)
+
+	timer := time.AfterFunc(time.Until(until), func() {
+		snapshot := MemberSnapshot{}
+		dbResult := m.db.Where(&MemberSnapshot{GuildID: guildID, UserID: userID}).First(&snapshot)
+		// the timeout was removed or changed in the meantime
+		if dbResult.Error != nil || snapshot.TimeoutUntil == nil || !snapshot.TimeoutUntil.Equal(until) {
+			return
+		}
+		m.timeoutTimers.Delete(key)
+
+		dbResult = m.db.Model(&snapshot).Update("timeout_until", nil)
+		if dbResult.Error != nil {
+			m.logger.Error("Error clearing member timeout in db", zap.String("guild", guildID), zap.String("user", userID), zap.Error(dbResult.Error))
+		}
+
+		data := map[string]string{
+			"member_id":          userID,
+			"member_full_name":   userFullName,
+			"timeout_until":      formatDiscordTimestamp(until),
+			"timeout_end_reason": "Expired",
+		}
+		(*auditLogAttribution)(nil).addToLogData(data)
+		m.sendLogToDiscord(guildID, MemberTimeoutRemove, data)
+	})
+	m.timeoutTimers.Set(key, timer)
+}
+
+func (m *Module) cancelTimeoutExpiry(guildID string, userID string) {
+	key := guildID + ":" + userID
+	if timer, ok := m.timeoutTimers.Get(key); ok {
+		timer.Stop()
+		m.timeoutTimers.Delete(key)
+	}
+}
+
+func formatDiscordTimestamp(t time.Time) string {
+	return "<t:" + strconv.FormatInt(t.Unix(), 10) + ":F>"
+}
@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// newCachedMessage returns false for messages without an author, like the updates discord sends after resolving the
+// link embeds of a message.
+func newCachedMessage(msg *discordgo.Message) (CachedMessage, bool) {
+	cachedMsg := CachedMessage{
+		ChannelID:        msg.ChannelID,
+		Content:          msg.Content,
+		Attachments:      cacheAttachments(msg.Attachments),
+		CreatedAt:        msg.Timestamp,
+		MentionedRoleIDs: msg.MentionRoles,
+		MentionsEveryone: msg.MentionEveryone,
+		Embeds:           cacheEmbeds(msg.Embeds),
+	}
+
+	if msg.Author != nil {
+		cachedMsg.AuthorID = msg.Author.ID
+		cachedMsg.AuthorFullName = msg.Author.String()
+	} else if len(msg.WebhookID) > 0 {
+		cachedMsg.AuthorID = msg.WebhookID
+		cachedMsg.AuthorFullName = "Webhook"
+	} else {
+		return cachedMsg, false
+	}
+
+	if cachedMsg.CreatedAt.IsZero() {
+		cachedMsg.CreatedAt, _ = discordgo.SnowflakeTimestamp(msg.ID)
+	}
+	if msg.EditedTimestamp != nil {
+		cachedMsg.EditedAt = *msg.EditedTimestamp
+	}
+	if msg.MessageReference != nil {
+		cachedMsg.ReplyToMessageID = msg.MessageReference.MessageID
+	}
+	if msg.ReferencedMessage != nil && msg.ReferencedMessage.Author != nil {
+		cachedMsg.ReplyToAuthorID = msg.ReferencedMessage.Author.ID
+		cachedMsg.ReplyToAuthorFullName = msg.ReferencedMessage.Author.String()
+	}
+	for _, user := range msg.Mentions {
+		cachedMsg.MentionedUserIDs = append(cachedMsg.MentionedUserIDs, user.ID)
+	}
+	for _, sticker := range msg.StickerItems {
+		cachedMsg.Stickers = append(cachedMsg.Stickers, sticker.Name)
+	}
+
+	return cachedMsg, true
+}
+
+func cacheEmbeds(embeds []*discordgo.MessageEmbed) []CachedEmbed {
+	var cached []CachedEmbed
+	for _, embed := range embeds {
+		if len(embed.Title) == 0 && len(embed.Description) == 0 {
+			continue
+		}
+		cached = append(cached, CachedEmbed{Title: embed.Title, Description: embed.Description})
+	}
+	return cached
+}
+
+// mergeEdit keeps the context an edit event does not repeat, edits only contain the referenced message sometimes.
+func (cm *CachedMessage) mergeEdit(edited CachedMessage) CachedMessage {
+	if len(edited.ReplyToAuthorID) == 0 {
+		edited.ReplyToAuthorID = cm.ReplyToAuthorID
+		edited.ReplyToAuthorFullName = cm.ReplyToAuthorFullName
+	}
+	if !cm.CreatedAt.IsZero() {
+		edited.CreatedAt = cm.CreatedAt
+	}
+	edited.Revisions = cm.Revisions
+	edited.DroppedRevisions = cm.DroppedRevisions
+	return edited
+}
+
+// addContextToLogData adds the placeholders shared by message edit and delete logs.
+func (cm *CachedMessage) addContextToLogData(messageID string, data map[string]string) {
+	createdAt := cm.CreatedAt
+	if createdAt.IsZero() {
+		createdAt, _ = discordgo.SnowflakeTimestamp(messageID)
+	}
+
+	replyToAuthor := ""
+	if len(cm.ReplyToAuthorFullName) > 0 {
+		replyToAuthor = cm.ReplyToAuthorFullName
+	} else if len(cm.ReplyToMessageID) > 0 {
+		replyToAuthor = "Unknown"
+	}
+
+	data["reply_to_author"] = replyToAuthor
+	data["reply_to_author_id"] = cm.ReplyToAuthorID
+	data["reply_to_message_id"] = cm.ReplyToMessageID
+	data["mentions"] = cm.mentions().format()
+	data["stickers"] = formatOptionalList(cm.Stickers)
+	data["embeds"] = formatEmbedList(cm.Embeds)
+	if cm.ContentOmitted {
+		data["stickers"] = contentNotCached
+		data["embeds"] = contentNotCached
+	}
+	data["message_created"] = strconv.FormatInt(createdAt.Unix(), 10)
+	data["message_age"] = formatDuration(time.Since(createdAt))
+}
+
+func formatOptionalList(items []string) string {
+	if len(items) == 0 {
+		return "None"
+	}
+	return strings.Join(items, ", ")
+}
+
+func formatEmbedList(embeds []CachedEmbed) string {
+	if len(embeds) == 0 {
+		return "None"
+	}
+	var parts []string
+	for _, embed := range embeds {
+		parts = append(parts, strings.TrimSpace(embed.Title+"\n"+embed.Description))
+	}
+	return strings.Join(parts, "\n\n")
+}
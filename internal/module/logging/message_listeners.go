@@ -5,6 +5,8 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"go.uber.org/zap"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,128 +18,271 @@ func (m *Module) registerMessageListeners() {
 }
 
 func (m *Module) handleMessageCreation(_ *discordgo.Session, msg *discordgo.MessageCreate) {
-	m.storeMessageInCache(msg.Message)
+	policy := m.getMessageCachePolicy(msg.GuildID)
+	// stored attachments are only re-uploaded with delete and edit logs
+	if m.cacheMessage(msg.Message, policy) && len(msg.Attachments) > 0 && (m.isLoggingEnabled(msg.GuildID, MessageDelete) || m.isLoggingEnabled(msg.GuildID, MessageEdit)) {
+		m.storeMessageAttachments(msg.Message, policy.TTL)
+	}
+}
+
+// cacheMessage stores the message unless it is ignored by all message log types, in which case only a marker is
+// stored. It returns whether the content was stored.
+func (m *Module) cacheMessage(msg *discordgo.Message, policy messageCachePolicy) bool {
+	if m.isIgnored(msg.GuildID, m.newMessageIgnoreSubject(msg), MessageEdit, MessageDelete, MessageBulkDelete) {
+		m.markMessageIgnored(msg.ID, policy)
+		return false
+	}
+	m.storeMessageInCache(msg, policy)
+	return m.cachesContentOf(policy, msg.ChannelID)
 }
 
 func (m *Module) handleMessageDeletion(_ *discordgo.Session, msg *discordgo.MessageDelete) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	cachedMsg := CachedMessage{}
-
-	err := m.cache.Get(ctx, "discord-msg:"+msg.ID).Scan(&cachedMsg)
+	cachedMsg, err := m.getCachedMessage(ctx, msg.ID)
 	if err != nil {
 		errorMsg := "<#" + msg.ChannelID + "> Message with ID *" + msg.ID + "* was deleted but the content could not be found in the bots cache."
 		m.sendErrorLogToDiscord(msg.GuildID, MessageDelete, errorMsg)
 		return
 	}
+	if cachedMsg.Ignored {
+		return
+	}
 
-	m.sendLogToDiscord(msg.GuildID, MessageDelete, map[string]string{
+	data := map[string]string{
 		"channel_id":       msg.ChannelID,
 		"author_id":        cachedMsg.AuthorID,
 		"author_full_name": cachedMsg.AuthorFullName,
-		"previous_content": cachedMsg.Content,
-	})
+		"previous_content": cachedMsg.contentForLog(),
+		"attachments":      formatAttachmentList(cachedMsg.Attachments),
+	}
+	if cachedMsg.ContentOmitted {
+		data["attachments"] = contentNotCached
+	}
+	cachedMsg.addContextToLogData(msg.ID, data)
+	pinged := cachedMsg.mentions()
+	ghostPingActive := !pinged.isEmpty() && m.isGhostPingDetectionActive(msg.GuildID)
+	if ghostPingActive || m.isLoggingEnabled(msg.GuildID, MessageDelete) {
+		// no audit log entry is created when authors delete their own messages
+		attribution := m.findMessageDeleteAttribution(msg.GuildID, msg.ChannelID, cachedMsg.AuthorID)
+		attribution.addToLogData(data)
+		if ghostPingActive && attribution == nil {
+			m.checkGhostPing(msg.GuildID, msg.ID, cachedMsg, pinged, "deleted")
+		}
+	}
+
+	files, closeFiles := m.openStoredAttachments(cachedMsg.Attachments)
+	defer closeFiles()
+
+	m.sendLogWithFilesToDiscord(msg.GuildID, MessageDelete, data, files)
 }
 
 func (m *Module) handleMessageBulkDeletion(_ *discordgo.Session, msgBulk *discordgo.MessageDeleteBulk) {
+	if !m.isLoggingEnabled(msgBulk.GuildID, MessageBulkDelete) {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
 	defer cancel()
 
-	// make sure messages are being logged in order
+	// make sure messages are listed in order
 	sortedIds := msgBulk.Messages
-	sort.Strings(sortedIds)
+	sort.Slice(sortedIds, func(i, j int) bool {
+		return compareSnowflakes(sortedIds[i], sortedIds[j]) < 0
+	})
+
+	var entries []transcriptEntry
+	authorMessageCounts := make(map[string]int)
+	var authorOrder []string
+	cachedCount := 0
+	redactionRuleSet := m.getRedactionRuleSet(msgBulk.GuildID)
+	redactions := 0
 
 	for _, msgID := range sortedIds {
+		cachedMsg, err := m.getCachedMessage(ctx, msgID)
+		if err != nil || cachedMsg.Ignored {
+			entries = append(entries, newTranscriptEntry(msgID, nil))
+			continue
+		}
+		cachedMsg.Content = cachedMsg.contentForLog()
+		redactions += redactionRuleSet.redactMessage(&cachedMsg)
+		entries = append(entries, newTranscriptEntry(msgID, &cachedMsg))
+		cachedCount++
 
-		cachedMsg := CachedMessage{}
+		if _, ok := authorMessageCounts[cachedMsg.AuthorFullName]; !ok {
+			authorOrder = append(authorOrder, cachedMsg.AuthorFullName)
+		}
+		authorMessageCounts[cachedMsg.AuthorFullName]++
+	}
+
+	var authors []string
+	for _, author := range authorOrder {
+		authors = append(authors, author+" ("+strconv.Itoa(authorMessageCounts[author])+")")
+	}
+	if len(authors) == 0 {
+		authors = append(authors, "Unknown")
+	}
+
+	channelName := m.getChannelName(msgBulk.ChannelID)
+	fileBaseName := "bulk-delete-" + channelName + "-" + strconv.FormatInt(time.Now().Unix(), 10)
 
-		err := m.cache.Get(ctx, "discord-msg:"+msgID).Scan(&cachedMsg)
+	files := []*discordgo.File{
+		{
+			Name:        fileBaseName + ".txt",
+			ContentType: "text/plain",
+			Reader:      strings.NewReader(buildTextTranscript(channelName, entries)),
+		},
+	}
+	htmlTranscript, err := buildHTMLTranscript(channelName, entries)
+	if err != nil {
+		m.logger.Error("Error rendering html transcript", zap.String("guild", msgBulk.GuildID), zap.String("channel", msgBulk.ChannelID), zap.Error(err))
+	} else {
+		files = append(files, &discordgo.File{
+			Name:        fileBaseName + ".html",
+			ContentType: "text/html",
+			Reader:      strings.NewReader(htmlTranscript),
+		})
+	}
+
+	data := map[string]string{
+		"channel_id":    msgBulk.ChannelID,
+		"message_count": strconv.Itoa(len(sortedIds)),
+		"cached_count":  strconv.Itoa(cachedCount),
+		"authors":       strings.Join(authors, ", "),
+		"redactions":    strconv.Itoa(redactions),
+	}
+	m.findAuditLogAttribution(msgBulk.GuildID, discordgo.AuditLogActionMessageBulkDelete, msgBulk.ChannelID).addToLogData(data)
+
+	m.sendLogWithFilesToDiscord(msgBulk.GuildID, MessageBulkDelete, data, files)
+}
+
+func (m *Module) getChannelName(channelID string) string {
+	channel, err := m.discord.State.Channel(channelID)
+	if err != nil {
+		channel, err = m.discord.Channel(channelID)
 		if err != nil {
-			errorMsg := "<#" + msgBulk.ChannelID + "> Message with ID *" + msgID + "* was deleted but the content could not be found in the bots cache."
-			m.sendErrorLogToDiscord(msgBulk.GuildID, MessageDelete, errorMsg)
-			continue
+			return channelID
 		}
+	}
+	return channel.Name
+}
 
-		m.sendLogToDiscord(msgBulk.GuildID, MessageDelete, map[string]string{
-			"channel_id":       msgBulk.ChannelID,
-			"author_id":        cachedMsg.AuthorID,
-			"author_full_name": cachedMsg.AuthorFullName,
-			"previous_content": cachedMsg.Content,
-		})
+// compareSnowflakes orders snowflake IDs numerically, which is not the same as ordering them as strings
+func compareSnowflakes(a string, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
 	}
+	return strings.Compare(a, b)
 }
 
 func (m *Module) handleMessageEdit(_ *discordgo.Session, msg *discordgo.MessageUpdate) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
 
-	cachedMsg := CachedMessage{}
-
-	err := m.cache.Get(ctx, "discord-msg:"+msg.ID).Scan(&cachedMsg)
+	cachedMsg, err := m.getCachedMessage(ctx, msg.ID)
 	if err != nil {
+		// embed updates of uncached messages don't change their content
+		if msg.Author == nil {
+			return
+		}
 		errorMsg := "<#" + msg.ChannelID + "> Message with ID *" + msg.ID + "* sent by *" + msg.Author.String() + "* was edited but the previous content could not be found in the bots cache."
 		m.sendErrorLogToDiscord(msg.GuildID, MessageEdit, errorMsg)
 		return
 	}
+	if cachedMsg.Ignored {
+		return
+	}
 
-	if msg.Author != nil {
-		m.sendLogToDiscord(msg.GuildID, MessageEdit, map[string]string{
-			"channel_id":       msg.ChannelID,
-			"author_id":        msg.Author.ID,
-			"author_full_name": msg.Author.String(),
-			"previous_content": cachedMsg.Content,
-		})
-	} else if len(msg.WebhookID) > 0 {
-		m.sendLogToDiscord(msg.GuildID, MessageEdit, map[string]string{
-			"channel_id":       msg.ChannelID,
-			"author_id":        msg.WebhookID,
-			"author_full_name": "Webhook",
-			"previous_content": cachedMsg.Content,
-		})
-	} else {
+	policy := m.getMessageCachePolicy(msg.GuildID)
+	editedMsg, ok := newCachedMessage(msg.Message)
+	if !ok {
 		if len(msg.Embeds) > 0 {
+			// resolved link embeds are not an edit, but should be known if the message is deleted
+			cachedMsg.Embeds = cacheEmbeds(msg.Embeds)
+			err = m.putCachedMessage(msg.ID, &cachedMsg, policy)
+			if err != nil {
+				m.logger.Warn("Error storing message in cache", zap.Error(err))
+			}
 			return
 		}
 		m.logger.Error("Message did not have user or webhook id!", zap.Any("message", msg))
 		return
 	}
+	editedMsg = cachedMsg.mergeEdit(editedMsg)
+	cachedMsg.recordRevision(msg.ID, &editedMsg)
 
-	m.storeMessageInCache(msg.Message)
-}
+	pinged := removedMentions(cachedMsg.mentions(), editedMsg.mentions())
+	if !pinged.isEmpty() && m.isGhostPingDetectionActive(msg.GuildID) {
+		m.checkGhostPing(msg.GuildID, msg.ID, cachedMsg, pinged, "edited")
+	}
 
-func (m *Module) storeMessageInCache(msg *discordgo.Message) {
-	var cachedMsg CachedMessage
+	removedAttachments := findRemovedAttachments(cachedMsg.Attachments, msg.Attachments)
+	files, closeFiles := m.openStoredAttachments(removedAttachments)
+	defer closeFiles()
 
-	if msg.Author != nil {
-		cachedMsg = CachedMessage{
-			ChannelID:      msg.ChannelID,
-			AuthorID:       msg.Author.ID,
-			AuthorFullName: msg.Author.String(),
-			Content:        msg.Content,
-		}
-	} else if len(msg.WebhookID) > 0 {
-		cachedMsg = CachedMessage{
-			ChannelID:      msg.ChannelID,
-			AuthorID:       msg.WebhookID,
-			AuthorFullName: "Webhook",
-			Content:        msg.Content,
-		}
-	} else {
-		if len(msg.Embeds) > 0 {
-			return
-		}
+	contentDiff := diffWords(cachedMsg.Content, msg.Content)
+
+	data := map[string]string{
+		"channel_id":          msg.ChannelID,
+		"author_id":           editedMsg.AuthorID,
+		"author_full_name":    editedMsg.AuthorFullName,
+		"previous_content":    cachedMsg.contentForLog(),
+		"new_content":         msg.Content,
+		"content_diff":        renderDiffANSI(contentDiff),
+		"content_diff_plain":  renderDiffPlain(contentDiff),
+		"removed_attachments": formatAttachmentList(removedAttachments),
+	}
+	if cachedMsg.ContentOmitted {
+		// without the previous content every word would show up as added
+		data["content_diff"] = contentNotCached
+		data["content_diff_plain"] = contentNotCached
+		data["removed_attachments"] = contentNotCached
+	}
+	editedMsg.addContextToLogData(msg.ID, data)
+	m.sendLogWithFilesToDiscord(msg.GuildID, MessageEdit, data, files)
+
+	err = m.putCachedMessage(msg.ID, &editedMsg, policy)
+	if err != nil {
+		m.logger.Warn("Error storing message in cache", zap.Error(err))
+	}
+}
+
+func (m *Module) storeMessageInCache(msg *discordgo.Message, policy messageCachePolicy) {
+	cachedMsg, ok := newCachedMessage(msg)
+	if !ok {
 		m.logger.Error("Message did not have user or webhook id!", zap.Any("message", msg))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	err := m.putCachedMessage(msg.ID, &cachedMsg, policy)
+	if err != nil {
+		m.logger.Warn("Error storing message in cache", zap.Error(err))
+	}
+}
 
-	ttl := time.Minute * time.Duration(m.config.Cache.MessageTTLMinutes)
+func (m *Module) getCachedMessage(ctx context.Context, messageID string) (CachedMessage, error) {
+	cachedMsg := CachedMessage{}
+	data, err := m.messages.Get(ctx, messageID)
+	if err != nil {
+		return cachedMsg, err
+	}
+	err = cachedMsg.UnmarshalBinary(data)
+	return cachedMsg, err
+}
 
-	err := m.cache.Set(ctx, "discord-msg:"+msg.ID, &cachedMsg, ttl).Err()
+// putCachedMessage stores the message for the ttl of the policy, without its content if the policy doesn't allow it.
+func (m *Module) putCachedMessage(messageID string, cachedMsg *CachedMessage, policy messageCachePolicy) error {
+	if !cachedMsg.Ignored && !m.cachesContentOf(policy, cachedMsg.ChannelID) {
+		cachedMsg.omitContent()
+	}
+	data, err := cachedMsg.MarshalBinary()
 	if err != nil {
-		m.logger.Warn("Error storing message in cache", zap.Error(err))
+		return err
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	return m.messages.Set(ctx, messageID, data, policy.TTL)
 }
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateLegacyLoggingSettings converts the single channel settings into destinations and drops the legacy table.
+func (m *Module) migrateLegacyLoggingSettings() error {
+	if !m.db.Migrator().HasTable(&GuildLoggingSetting{}) {
+		return nil
+	}
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var settings []GuildLoggingSetting
+		err := tx.Find(&settings).Error
+		if err != nil {
+			return err
+		}
+
+		var destinations []GuildLoggingDestination
+		for _, setting := range settings {
+			// settings without a channel could never send logs
+			if len(setting.LoggingChannelID) == 0 {
+				continue
+			}
+			outputMode := setting.OutputMode
+			if len(outputMode) == 0 {
+				outputMode = OutputModeText
+			}
+			destinations = append(destinations, GuildLoggingDestination{
+				GuildID:    setting.GuildID,
+				LogType:    setting.LogType,
+				ChannelID:  setting.LoggingChannelID,
+				Enabled:    setting.Enabled,
+				Format:     setting.Format,
+				OutputMode: outputMode,
+			})
+		}
+
+		if len(destinations) > 0 {
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&destinations).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		m.logger.Info("Migrated legacy logging settings", zap.Int("settings", len(settings)), zap.Int("destinations", len(destinations)))
+		return tx.Migrator().DropTable(&GuildLoggingSetting{})
+	})
+}
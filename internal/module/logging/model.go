@@ -2,6 +2,7 @@ package logging
 
 import (
 	"encoding/json"
+	"time"
 )
 
 type CachedMessage struct {
@@ -9,6 +10,45 @@ type CachedMessage struct {
 	AuthorID       string
 	AuthorFullName string
 	Content        string
+	Attachments    []CachedAttachment
+	// CreatedAt is missing for messages cached by older versions, the message id contains it as well
+	CreatedAt             time.Time
+	ReplyToMessageID      string
+	ReplyToAuthorID       string
+	ReplyToAuthorFullName string
+	MentionedUserIDs      []string
+	MentionedRoleIDs      []string
+	MentionsEveryone      bool
+	Stickers              []string
+	Embeds                []CachedEmbed
+	// EditedAt is the time the current content was set, it is zero for messages that were never edited
+	EditedAt time.Time
+	// Revisions are the previous versions of the message, oldest first
+	Revisions        []CachedRevision `json:",omitempty"`
+	DroppedRevisions int              `json:",omitempty"`
+	// ContentOmitted is set if the cache policy of the guild does not allow storing the content
+	ContentOmitted bool `json:",omitempty"`
+	// Ignored messages are stored without content, so their edits and deletions are not reported as cache misses
+	Ignored bool `json:",omitempty"`
+}
+
+type CachedEmbed struct {
+	Title       string
+	Description string
+}
+
+type CachedRevision struct {
+	Content     string
+	Attachments []string
+	Timestamp   time.Time
+}
+
+type CachedAttachment struct {
+	ID          string
+	Filename    string
+	Size        int
+	ContentType string
+	URL         string
 }
 
 func (cm *CachedMessage) MarshalBinary() ([]byte, error) {
@@ -19,39 +59,128 @@ func (cm *CachedMessage) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, cm)
 }
 
+type CachedInvite struct {
+	Code            string
+	Uses            int
+	MaxUses         int
+	ChannelID       string
+	InviterID       string
+	InviterFullName string
+}
+
+func (ci *CachedInvite) MarshalBinary() ([]byte, error) {
+	return json.Marshal(ci)
+}
+
+func (ci *CachedInvite) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, ci)
+}
+
 type LogType string
 
 const (
-	MessageEdit      LogType = "message_edit"
-	MessageDelete    LogType = "message_delete"
-	MemberJoin       LogType = "member_join"
-	MemberLeave      LogType = "member_leave"
-	MemberRoleChange LogType = "member_role_change"
-	GuildBanAdd      LogType = "guild_ban_add"
-	GuildBanRemove   LogType = "guild_ban_remove"
+	MessageEdit          LogType = "message_edit"
+	MessageDelete        LogType = "message_delete"
+	MessageBulkDelete    LogType = "message_bulk_delete"
+	MemberJoin           LogType = "member_join"
+	MemberLeave          LogType = "member_leave"
+	MemberKick           LogType = "member_kick"
+	MemberRoleChange     LogType = "member_role_change"
+	GuildBanAdd          LogType = "guild_ban_add"
+	GuildBanRemove       LogType = "guild_ban_remove"
+	ChannelCreate        LogType = "channel_create"
+	ChannelDelete        LogType = "channel_delete"
+	ChannelUpdate        LogType = "channel_update"
+	RoleCreate           LogType = "role_create"
+	RoleDelete           LogType = "role_delete"
+	RoleUpdate           LogType = "role_update"
+	MemberNicknameChange LogType = "member_nickname_change"
+	UserProfileChange    LogType = "user_profile_change"
+	VoiceJoin            LogType = "voice_join"
+	VoiceLeave           LogType = "voice_leave"
+	VoiceMove            LogType = "voice_move"
+	VoiceStateChange     LogType = "voice_state_change"
+	InviteCreate         LogType = "invite_create"
+	InviteDelete         LogType = "invite_delete"
+	MemberTimeoutAdd     LogType = "member_timeout_add"
+	MemberTimeoutRemove  LogType = "member_timeout_remove"
+	GhostPing            LogType = "ghost_ping"
 )
 
 var (
 	logTypeReadableStringsMap = map[LogType]string{
-		MessageEdit:      "Message Edit",
-		MessageDelete:    "Message Delete",
-		MemberJoin:       "Member Join",
-		MemberLeave:      "Member Leave",
-		MemberRoleChange: "Member Role Change",
-		GuildBanAdd:      "User Banned",
-		GuildBanRemove:   "User Unbanned",
+		MessageEdit:          "Message Edit",
+		MessageDelete:        "Message Delete",
+		MessageBulkDelete:    "Message Bulk Delete",
+		MemberJoin:           "Member Join",
+		MemberLeave:          "Member Leave",
+		MemberKick:           "Member Kick",
+		MemberRoleChange:     "Member Role Change",
+		GuildBanAdd:          "User Banned",
+		GuildBanRemove:       "User Unbanned",
+		ChannelCreate:        "Channel Create",
+		ChannelDelete:        "Channel Delete",
+		ChannelUpdate:        "Channel Update",
+		RoleCreate:           "Role Create",
+		RoleDelete:           "Role Delete",
+		RoleUpdate:           "Role Update",
+		MemberNicknameChange: "Nickname Change",
+		UserProfileChange:    "User Profile Change",
+		VoiceJoin:            "Voice Join",
+		VoiceLeave:           "Voice Leave",
+		VoiceMove:            "Voice Move",
+		VoiceStateChange:     "Voice State Change",
+		InviteCreate:         "Invite Create",
+		InviteDelete:         "Invite Delete",
+		MemberTimeoutAdd:     "Member Timeout",
+		MemberTimeoutRemove:  "Member Timeout Removed",
+		GhostPing:            "Ghost Ping",
 	}
 	logTypeParseMap = map[string]LogType{
-		"message_edit":       MessageEdit,
-		"message_delete":     MessageDelete,
-		"member_join":        MemberJoin,
-		"member_leave":       MemberLeave,
-		"member_role_change": MemberRoleChange,
-		"guild_ban_add":      GuildBanAdd,
-		"guild_ban_remove":   GuildBanRemove,
+		"message_edit":           MessageEdit,
+		"message_delete":         MessageDelete,
+		"message_bulk_delete":    MessageBulkDelete,
+		"member_join":            MemberJoin,
+		"member_leave":           MemberLeave,
+		"member_kick":            MemberKick,
+		"member_role_change":     MemberRoleChange,
+		"guild_ban_add":          GuildBanAdd,
+		"guild_ban_remove":       GuildBanRemove,
+		"channel_create":         ChannelCreate,
+		"channel_delete":         ChannelDelete,
+		"channel_update":         ChannelUpdate,
+		"role_create":            RoleCreate,
+		"role_delete":            RoleDelete,
+		"role_update":            RoleUpdate,
+		"member_nickname_change": MemberNicknameChange,
+		"user_profile_change":    UserProfileChange,
+		"voice_join":             VoiceJoin,
+		"voice_leave":            VoiceLeave,
+		"voice_move":             VoiceMove,
+		"voice_state_change":     VoiceStateChange,
+		"invite_create":          InviteCreate,
+		"invite_delete":          InviteDelete,
+		"member_timeout_add":     MemberTimeoutAdd,
+		"member_timeout_remove":  MemberTimeoutRemove,
+		"ghost_ping":             GhostPing,
+	}
+	// logTypeCategories groups the log types for overviews, since discord allows at most 25 choices and embed fields
+	logTypeCategories = []logTypeCategory{
+		{Name: "Messages", LogTypes: []LogType{MessageEdit, MessageDelete, MessageBulkDelete, GhostPing}},
+		{Name: "Members", LogTypes: []LogType{MemberJoin, MemberLeave, MemberRoleChange, MemberNicknameChange, UserProfileChange}},
+		{Name: "Moderation", LogTypes: []LogType{MemberKick, GuildBanAdd, GuildBanRemove, MemberTimeoutAdd, MemberTimeoutRemove}},
+		{Name: "Channels", LogTypes: []LogType{ChannelCreate, ChannelDelete, ChannelUpdate}},
+		{Name: "Roles", LogTypes: []LogType{RoleCreate, RoleDelete, RoleUpdate}},
+		{Name: "Voice", LogTypes: []LogType{VoiceJoin, VoiceLeave, VoiceMove, VoiceStateChange}},
+		{Name: "Invites", LogTypes: []LogType{InviteCreate, InviteDelete}},
 	}
 )
 
+type logTypeCategory struct {
+	Name     string
+	LogTypes []LogType
+}
+
 func (lt LogType) ToReadableString() string {
 	return logTypeReadableStringsMap[lt]
 }
@@ -61,6 +190,44 @@ func ParseLogType(str string) (LogType, bool) {
 	return v, ok
 }
 
+// logTypeFallbacks sends log types to the destinations of another type while they are not configured. Bulk deletions
+// were logged as message deletions before they had their own type.
+var logTypeFallbacks = map[LogType]LogType{
+	MessageBulkDelete: MessageDelete,
+}
+
+type LogOutputMode string
+
+const (
+	OutputModeText  LogOutputMode = "text"
+	OutputModeEmbed LogOutputMode = "embed"
+)
+
+var (
+	outputModeReadableStringsMap = map[LogOutputMode]string{
+		OutputModeText:  "Text",
+		OutputModeEmbed: "Embed",
+	}
+	outputModeParseMap = map[string]LogOutputMode{
+		"text":  OutputModeText,
+		"embed": OutputModeEmbed,
+	}
+)
+
+func (om LogOutputMode) ToReadableString() string {
+	if str, ok := outputModeReadableStringsMap[om]; ok {
+		return str
+	}
+	return outputModeReadableStringsMap[OutputModeText]
+}
+
+func ParseLogOutputMode(str string) (LogOutputMode, bool) {
+	v, ok := outputModeParseMap[str]
+	return v, ok
+}
+
+// GuildLoggingSetting is the legacy single channel configuration, it is only kept to migrate existing settings to
+// GuildLoggingDestination.
 type GuildLoggingSetting struct {
 	ID               uint    `gorm:"primaryKey"`
 	GuildID          string  `gorm:"uniqueIndex:logging_server_type_idx"`
@@ -68,4 +235,126 @@ type GuildLoggingSetting struct {
 	Enabled          bool
 	LoggingChannelID string
 	Format           string
+	OutputMode       LogOutputMode `gorm:"default:text"`
+}
+
+type GuildLoggingDestination struct {
+	ID         uint    `gorm:"primaryKey"`
+	GuildID    string  `gorm:"uniqueIndex:logging_destination_idx"`
+	LogType    LogType `gorm:"uniqueIndex:logging_destination_idx"`
+	ChannelID  string  `gorm:"uniqueIndex:logging_destination_idx"`
+	Enabled    bool
+	Format     string
+	OutputMode LogOutputMode `gorm:"default:text"`
+}
+
+type IgnoreTargetType string
+
+const (
+	IgnoreTargetChannel  IgnoreTargetType = "channel"
+	IgnoreTargetCategory IgnoreTargetType = "category"
+	IgnoreTargetRole     IgnoreTargetType = "role"
+	IgnoreTargetUser     IgnoreTargetType = "user"
+	IgnoreTargetBots     IgnoreTargetType = "bots"
+	IgnoreTargetWebhooks IgnoreTargetType = "webhooks"
+)
+
+// LoggingIgnoreRule suppresses logs matching the target, an empty LogType applies the rule to all log types.
+type LoggingIgnoreRule struct {
+	ID         uint             `gorm:"primaryKey"`
+	GuildID    string           `gorm:"uniqueIndex:logging_ignore_idx"`
+	LogType    LogType          `gorm:"uniqueIndex:logging_ignore_idx"`
+	TargetType IgnoreTargetType `gorm:"uniqueIndex:logging_ignore_idx"`
+	TargetID   string           `gorm:"uniqueIndex:logging_ignore_idx"`
+}
+
+// GuildLoggingConfig holds guild wide logging settings that are not specific to a log type.
+type GuildLoggingConfig struct {
+	ID                     uint   `gorm:"primaryKey"`
+	GuildID                string `gorm:"uniqueIndex"`
+	ArchiveRetentionDays   *int
+	CacheWarmupMessages    *int
+	GhostPingWindowSeconds *int
+	GhostPingNotice        bool
+	CacheTTLMinutes        *int
+	CacheContent           *bool
+}
+
+type RedactionDetector string
+
+const (
+	RedactionDetectorEmail        RedactionDetector = "email"
+	RedactionDetectorPhone        RedactionDetector = "phone"
+	RedactionDetectorIBAN         RedactionDetector = "iban"
+	RedactionDetectorDiscordToken RedactionDetector = "discord_token"
+	RedactionDetectorCustom       RedactionDetector = "custom"
+)
+
+var (
+	redactionDetectorReadableStringsMap = map[RedactionDetector]string{
+		RedactionDetectorEmail:        "Email Address",
+		RedactionDetectorPhone:        "Phone Number",
+		RedactionDetectorIBAN:         "IBAN",
+		RedactionDetectorDiscordToken: "Discord Token",
+		RedactionDetectorCustom:       "Custom Pattern",
+	}
+	redactionDetectorParseMap = map[string]RedactionDetector{
+		"email":         RedactionDetectorEmail,
+		"phone":         RedactionDetectorPhone,
+		"iban":          RedactionDetectorIBAN,
+		"discord_token": RedactionDetectorDiscordToken,
+		"custom":        RedactionDetectorCustom,
+	}
+)
+
+func (rd RedactionDetector) ToReadableString() string {
+	if str, ok := redactionDetectorReadableStringsMap[rd]; ok {
+		return str
+	}
+	return string(rd)
+}
+
+func ParseRedactionDetector(str string) (RedactionDetector, bool) {
+	v, ok := redactionDetectorParseMap[str]
+	return v, ok
+}
+
+// RedactionRule removes matches of a built-in detector or a custom regular expression from message content before it
+// is logged. Pattern is only used by custom rules.
+type RedactionRule struct {
+	ID       uint              `gorm:"primaryKey"`
+	GuildID  string            `gorm:"uniqueIndex:logging_redaction_idx"`
+	Detector RedactionDetector `gorm:"uniqueIndex:logging_redaction_idx"`
+	Pattern  string            `gorm:"uniqueIndex:logging_redaction_idx"`
+}
+
+// GuildCacheChannel limits caching of message content to specific channels, threads and channels of categories.
+// Guilds without entries cache the content of all channels.
+type GuildCacheChannel struct {
+	ID        uint   `gorm:"primaryKey"`
+	GuildID   string `gorm:"uniqueIndex:logging_cache_channel_idx"`
+	ChannelID string `gorm:"uniqueIndex:logging_cache_channel_idx"`
+}
+
+// LogRecord is an archived log event, Payload contains the placeholder data as JSON.
+type LogRecord struct {
+	ID        uint      `gorm:"primaryKey"`
+	GuildID   string    `gorm:"index:log_record_guild_time_idx"`
+	CreatedAt time.Time `gorm:"index:log_record_guild_time_idx"`
+	LogType   LogType
+	ActorID   string `gorm:"index"`
+	TargetID  string `gorm:"index"`
+	ChannelID string
+	Payload   string
+}
+
+type MemberSnapshot struct {
+	ID           uint   `gorm:"primaryKey"`
+	GuildID      string `gorm:"uniqueIndex:member_snapshot_guild_user_idx"`
+	UserID       string `gorm:"uniqueIndex:member_snapshot_guild_user_idx"`
+	Nickname     string
+	Username     string
+	Avatar       string
+	GuildAvatar  string
+	TimeoutUntil *time.Time
 }
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"strings"
+)
+
+var (
+	// ordered like in the Discord client
+	permissionNames = []struct {
+		Permission int64
+		Name       string
+	}{
+		{discordgo.PermissionAdministrator, "Administrator"},
+		{discordgo.PermissionViewChannel, "View Channel"},
+		{discordgo.PermissionManageChannels, "Manage Channels"},
+		{discordgo.PermissionManageRoles, "Manage Roles"},
+		{discordgo.PermissionManageEmojis, "Manage Emojis and Stickers"},
+		{discordgo.PermissionViewAuditLogs, "View Audit Log"},
+		{discordgo.PermissionViewGuildInsights, "View Server Insights"},
+		{discordgo.PermissionManageWebhooks, "Manage Webhooks"},
+		{discordgo.PermissionManageServer, "Manage Server"},
+		{discordgo.PermissionCreateInstantInvite, "Create Invite"},
+		{discordgo.PermissionChangeNickname, "Change Nickname"},
+		{discordgo.PermissionManageNicknames, "Manage Nicknames"},
+		{discordgo.PermissionKickMembers, "Kick Members"},
+		{discordgo.PermissionBanMembers, "Ban Members"},
+		{discordgo.PermissionModerateMembers, "Timeout Members"},
+		{discordgo.PermissionSendMessages, "Send Messages"},
+		{discordgo.PermissionSendMessagesInThreads, "Send Messages in Threads"},
+		{discordgo.PermissionCreatePublicThreads, "Create Public Threads"},
+		{discordgo.PermissionCreatePrivateThreads, "Create Private Threads"},
+		{discordgo.PermissionEmbedLinks, "Embed Links"},
+		{discordgo.PermissionAttachFiles, "Attach Files"},
+		{discordgo.PermissionAddReactions, "Add Reactions"},
+		{discordgo.PermissionUseExternalEmojis, "Use External Emoji"},
+		{discordgo.PermissionUseExternalStickers, "Use External Stickers"},
+		{discordgo.PermissionMentionEveryone, "Mention Everyone"},
+		{discordgo.PermissionManageMessages, "Manage Messages"},
+		{discordgo.PermissionManageThreads, "Manage Threads"},
+		{discordgo.PermissionReadMessageHistory, "Read Message History"},
+		{discordgo.PermissionSendTTSMessages, "Send Text-to-Speech Messages"},
+		{discordgo.PermissionUseSlashCommands, "Use Application Commands"},
+		{discordgo.PermissionVoiceConnect, "Connect"},
+		{discordgo.PermissionVoiceSpeak, "Speak"},
+		{discordgo.PermissionVoiceStreamVideo, "Video"},
+		{discordgo.PermissionUseActivities, "Use Activities"},
+		{discordgo.PermissionVoiceUseVAD, "Use Voice Activity"},
+		{discordgo.PermissionVoicePrioritySpeaker, "Priority Speaker"},
+		{discordgo.PermissionVoiceMuteMembers, "Mute Members"},
+		{discordgo.PermissionVoiceDeafenMembers, "Deafen Members"},
+		{discordgo.PermissionVoiceMoveMembers, "Move Members"},
+		{discordgo.PermissionVoiceRequestToSpeak, "Request to Speak"},
+		{discordgo.PermissionManageEvents, "Manage Events"},
+	}
+)
+
+func mapPermissionsToNames(permissions int64) []string {
+	var names []string
+	known := int64(0)
+	for _, permission := range permissionNames {
+		known |= permission.Permission
+		if permissions&permission.Permission != 0 {
+			names = append(names, permission.Name)
+		}
+	}
+	if permissions&^known != 0 {
+		names = append(names, "Unknown Permissions")
+	}
+	return names
+}
+
+// formatPermissionDifferences renders added and removed permission bits, e.g. "+Ban Members, -Manage Webhooks".
+func formatPermissionDifferences(oldPermissions int64, newPermissions int64) string {
+	var changes []string
+	for _, name := range mapPermissionsToNames(newPermissions &^ oldPermissions) {
+		changes = append(changes, "+"+name)
+	}
+	for _, name := range mapPermissionsToNames(oldPermissions &^ newPermissions) {
+		changes = append(changes, "-"+name)
+	}
+	return strings.Join(changes, ", ")
+}
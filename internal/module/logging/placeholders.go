@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"github.com/yannismate/gowlbot/internal/templating"
+	"strings"
+)
+
+var (
+	attributionPlaceholders    = []string{"moderator_id", "moderator_full_name", "reason"}
+	messagePlaceholders        = []string{"channel_id", "author_id", "author_full_name"}
+	messageContextPlaceholders = []string{"reply_to_author", "reply_to_author_id", "reply_to_message_id", "mentions", "stickers", "embeds",
+		"message_created", "message_age"}
+	redactionPlaceholders = []string{"redactions"}
+	memberPlaceholders    = []string{"member_id", "member_full_name"}
+	channelPlaceholders   = []string{"channel_id", "channel_name", "channel_type", "parent_id", "parent_name"}
+	rolePlaceholders      = []string{"role_id", "role_name", "role_color"}
+	voicePlaceholders     = []string{"channel_id", "old_channel_id"}
+	invitePlaceholders    = []string{"invite_code", "channel_id", "inviter_id", "inviter_full_name"}
+
+	// logTypePlaceholders lists the placeholders each listener provides, formats are validated against them
+	logTypePlaceholders = map[LogType][][]string{
+		MessageEdit:          {messagePlaceholders, messageContextPlaceholders, redactionPlaceholders, {"previous_content", "new_content", "content_diff", "content_diff_plain", "removed_attachments"}},
+		MessageDelete:        {messagePlaceholders, messageContextPlaceholders, attributionPlaceholders, redactionPlaceholders, {"previous_content", "attachments"}},
+		MessageBulkDelete:    {attributionPlaceholders, redactionPlaceholders, {"channel_id", "message_count", "cached_count", "authors"}},
+		MemberJoin:           {memberPlaceholders, {"guild_member_count", "invite_code", "invite_uses", "inviter_id", "inviter_full_name"}},
+		MemberLeave:          {memberPlaceholders, {"guild_member_count", "kicked_by"}},
+		MemberKick:           {memberPlaceholders, attributionPlaceholders, {"guild_member_count"}},
+		MemberRoleChange:     {memberPlaceholders, attributionPlaceholders, {"old_roles", "new_roles", "role_changes"}},
+		GuildBanAdd:          {memberPlaceholders, attributionPlaceholders},
+		GuildBanRemove:       {memberPlaceholders, attributionPlaceholders},
+		ChannelCreate:        {channelPlaceholders, attributionPlaceholders},
+		ChannelDelete:        {channelPlaceholders, attributionPlaceholders},
+		ChannelUpdate:        {channelPlaceholders, attributionPlaceholders, {"changes"}},
+		RoleCreate:           {rolePlaceholders, attributionPlaceholders, {"permissions"}},
+		RoleDelete:           {rolePlaceholders, attributionPlaceholders, {"permissions"}},
+		RoleUpdate:           {rolePlaceholders, attributionPlaceholders, {"old_role_name", "changes", "permission_changes"}},
+		MemberNicknameChange: {memberPlaceholders, attributionPlaceholders, {"old_nickname", "new_nickname"}},
+		UserProfileChange:    {memberPlaceholders, {"old_username", "new_username", "new_avatar_url", "changes"}},
+		VoiceJoin:            {memberPlaceholders, voicePlaceholders},
+		VoiceLeave:           {memberPlaceholders, voicePlaceholders, {"session_duration"}},
+		VoiceMove:            {memberPlaceholders, voicePlaceholders},
+		VoiceStateChange:     {memberPlaceholders, voicePlaceholders, attributionPlaceholders, {"changes"}},
+		InviteCreate:         {invitePlaceholders, {"max_uses", "max_age", "temporary"}},
+		InviteDelete:         {invitePlaceholders, {"invite_uses"}},
+		MemberTimeoutAdd:     {memberPlaceholders, attributionPlaceholders, {"timeout_until"}},
+		MemberTimeoutRemove:  {memberPlaceholders, attributionPlaceholders, {"timeout_until", "timeout_end_reason"}},
+		GhostPing:            {messagePlaceholders, messageContextPlaceholders, redactionPlaceholders, {"mentions", "ghost_ping_action", "previous_content", "message_created", "message_age"}},
+	}
+)
+
+// getLogTypePlaceholders returns all placeholders available in formats of the given log type.
+func getLogTypePlaceholders(logType LogType) []string {
+	placeholders := []string{"time"}
+	for _, group := range logTypePlaceholders[logType] {
+		placeholders = append(placeholders, group...)
+	}
+	return placeholders
+}
+
+func validateLogFormat(logType LogType, format string) error {
+	tmpl, err := templating.Parse(format)
+	if err != nil {
+		return err
+	}
+	return tmpl.Validate(getLogTypePlaceholders(logType))
+}
+
+func formatPlaceholderList(logType LogType) string {
+	placeholders := getLogTypePlaceholders(logType)
+	for i, placeholder := range placeholders {
+		placeholders[i] = "`{" + placeholder + "}`"
+	}
+	return strings.Join(placeholders, ", ")
+}
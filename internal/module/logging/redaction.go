@@ -0,0 +1,210 @@
+package logging
+
+import (
+	"errors"
+	"go.uber.org/zap"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const maxRedactionPatternLength = 200
+
+var (
+	errRedactionPatternTooLong    = errors.New("the pattern is longer than " + strconv.Itoa(maxRedactionPatternLength) + " characters")
+	errRedactionPatternMatchesAll = errors.New("the pattern matches empty text")
+
+	// redactedPlaceholders contain message content, the rendered diffs are built again from the redacted content
+	redactedPlaceholders = []string{"previous_content", "new_content", "embeds"}
+
+	// redactionDetectorOrder applies specific detectors first, so phone numbers don't match parts of IBANs or tokens
+	redactionDetectorOrder = []RedactionDetector{RedactionDetectorDiscordToken, RedactionDetectorIBAN, RedactionDetectorEmail,
+		RedactionDetectorPhone, RedactionDetectorCustom}
+
+	isoDateRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+	builtInRedactors = map[RedactionDetector]redactor{
+		RedactionDetectorEmail: {
+			regex:       regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+			replacement: "[email redacted]",
+		},
+		RedactionDetectorPhone: {
+			regex:       regexp.MustCompile(`(?:\+|\()?\b\d[\d \-()]{5,20}\d\b`),
+			validate:    isPhoneNumber,
+			replacement: "[phone number redacted]",
+		},
+		RedactionDetectorIBAN: {
+			regex:       regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,4})?\b`),
+			validate:    isIBAN,
+			replacement: "[IBAN redacted]",
+		},
+		RedactionDetectorDiscordToken: {
+			regex:       regexp.MustCompile(`\b(?:mfa\.[\w-]{80,}|[\w-]{23,28}\.[\w-]{6,7}\.[\w-]{27,})`),
+			replacement: "[token redacted]",
+		},
+	}
+)
+
+type redactor struct {
+	regex *regexp.Regexp
+	// validate filters matches of the regex, if it is set
+	validate    func(match string) bool
+	replacement string
+}
+
+type redactionRuleSet []redactor
+
+// redact replaces all matches of the rules and returns the number of replacements.
+func (rs redactionRuleSet) redact(content string) (string, int) {
+	count := 0
+	for _, r := range rs {
+		content = r.regex.ReplaceAllStringFunc(content, func(match string) string {
+			if r.validate != nil && !r.validate(match) {
+				return match
+			}
+			count++
+			return r.replacement
+		})
+	}
+	return content, count
+}
+
+// redactMessage redacts the content and embeds of a cached message, for logs that include whole messages as files.
+func (rs redactionRuleSet) redactMessage(cachedMsg *CachedMessage) int {
+	var count, n int
+	cachedMsg.Content, count = rs.redact(cachedMsg.Content)
+	for i := range cachedMsg.Embeds {
+		cachedMsg.Embeds[i].Title, n = rs.redact(cachedMsg.Embeds[i].Title)
+		count += n
+		cachedMsg.Embeds[i].Description, n = rs.redact(cachedMsg.Embeds[i].Description)
+		count += n
+	}
+	return count
+}
+
+func newRedactor(rule RedactionRule) (redactor, error) {
+	if rule.Detector != RedactionDetectorCustom {
+		builtIn, ok := builtInRedactors[rule.Detector]
+		if !ok {
+			return redactor{}, errors.New("unknown detector " + string(rule.Detector))
+		}
+		return builtIn, nil
+	}
+	regex, err := compileRedactionPattern(rule.Pattern)
+	if err != nil {
+		return redactor{}, err
+	}
+	return redactor{regex: regex, replacement: "[redacted]"}, nil
+}
+
+// compileRedactionPattern rejects patterns matching empty text, they would insert a replacement between every
+// character.
+func compileRedactionPattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxRedactionPatternLength {
+		return nil, errRedactionPatternTooLong
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if regex.MatchString("") {
+		return nil, errRedactionPatternMatchesAll
+	}
+	return regex, nil
+}
+
+func (m *Module) getRedactionRuleSet(guildID string) redactionRuleSet {
+	if ruleSet, ok := m.redactionRuleSets.Get(guildID); ok {
+		return ruleSet
+	}
+
+	var rules []RedactionRule
+	result := m.db.Where(&RedactionRule{GuildID: guildID}).Order("id").Find(&rules)
+	if result.Error != nil {
+		m.logger.Error("Error fetching redaction rules from db", zap.String("guild", guildID), zap.Error(result.Error))
+		return nil
+	}
+
+	var ruleSet redactionRuleSet
+	for _, detector := range redactionDetectorOrder {
+		for _, rule := range rules {
+			if rule.Detector != detector {
+				continue
+			}
+			r, err := newRedactor(rule)
+			if err != nil {
+				m.logger.Warn("Skipping invalid redaction rule", zap.String("guild", guildID), zap.Uint("rule", rule.ID), zap.Error(err))
+				continue
+			}
+			ruleSet = append(ruleSet, r)
+		}
+	}
+	m.redactionRuleSets.Set(guildID, ruleSet)
+	return ruleSet
+}
+
+// redactLogData redacts the message content of a log and sets the redactions placeholder. Listeners can report
+// redactions of their files, like transcripts, by setting the placeholder beforehand.
+func (m *Module) redactLogData(guildID string, data map[string]string) {
+	count, _ := strconv.Atoi(data["redactions"])
+
+	ruleSet := m.getRedactionRuleSet(guildID)
+	if len(ruleSet) > 0 {
+		for _, key := range redactedPlaceholders {
+			value, ok := data[key]
+			if !ok {
+				continue
+			}
+			redacted, n := ruleSet.redact(value)
+			data[key] = redacted
+			count += n
+		}
+		// matches can span the markers of a rendered diff, so it is rendered again from the redacted content
+		if contentDiff, ok := data["content_diff"]; ok && contentDiff != contentNotCached {
+			diff := diffWords(data["previous_content"], data["new_content"])
+			data["content_diff"] = renderDiffANSI(diff)
+			data["content_diff_plain"] = renderDiffPlain(diff)
+		}
+	}
+
+	data["redactions"] = ""
+	if count > 0 {
+		data["redactions"] = strconv.Itoa(count)
+	}
+}
+
+// isPhoneNumber requires the length of international numbers and a separator or country code, to not match ids,
+// timestamps and dates.
+func isPhoneNumber(match string) bool {
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	if digits < 8 || digits > 15 || isoDateRegex.MatchString(match) {
+		return false
+	}
+	return strings.HasPrefix(match, "+") || strings.ContainsAny(match, " -()")
+}
+
+// isIBAN verifies the ISO 13616 check digits.
+func isIBAN(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, c := range rearranged {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"time"
+)
+
+const maxCachedRevisions = 25
+
+// versionTimestamp returns when the current content of the message was set.
+func (cm *CachedMessage) versionTimestamp(messageID string) time.Time {
+	if !cm.EditedAt.IsZero() {
+		return cm.EditedAt
+	}
+	if !cm.CreatedAt.IsZero() {
+		return cm.CreatedAt
+	}
+	timestamp, _ := discordgo.SnowflakeTimestamp(messageID)
+	return timestamp
+}
+
+func (cm *CachedMessage) currentRevision(messageID string) CachedRevision {
+	var attachments []string
+	for _, attachment := range cm.Attachments {
+		attachments = append(attachments, attachment.Filename)
+	}
+	return CachedRevision{
+		Content:     cm.Content,
+		Attachments: attachments,
+		Timestamp:   cm.versionTimestamp(messageID),
+	}
+}
+
+// recordRevision adds the cached version to the revisions of the edited message if its content or attachments
+// changed. Only the latest maxCachedRevisions are kept.
+func (cm *CachedMessage) recordRevision(messageID string, edited *CachedMessage) {
+	// without the cached content there is nothing to compare against
+	if cm.ContentOmitted || cm.Content == edited.Content && sameAttachments(cm.Attachments, edited.Attachments) {
+		if edited.EditedAt.IsZero() {
+			edited.EditedAt = cm.EditedAt
+		}
+		return
+	}
+
+	revisions := make([]CachedRevision, 0, len(cm.Revisions)+1)
+	revisions = append(revisions, cm.Revisions...)
+	revisions = append(revisions, cm.currentRevision(messageID))
+	if len(revisions) > maxCachedRevisions {
+		edited.DroppedRevisions += len(revisions) - maxCachedRevisions
+		revisions = revisions[len(revisions)-maxCachedRevisions:]
+	}
+	edited.Revisions = revisions
+
+	if edited.EditedAt.IsZero() {
+		edited.EditedAt = time.Now()
+	}
+}
+
+func sameAttachments(a []CachedAttachment, b []CachedAttachment) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
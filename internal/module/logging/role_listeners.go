@@ -0,0 +1,145 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"strconv"
+	"strings"
+)
+
+type roleSnapshot struct {
+	GuildID     string
+	Name        string
+	Color       int
+	Hoist       bool
+	Mentionable bool
+	Permissions int64
+}
+
+func newRoleSnapshot(guildID string, role *discordgo.Role) roleSnapshot {
+	return roleSnapshot{
+		GuildID:     guildID,
+		Name:        role.Name,
+		Color:       role.Color,
+		Hoist:       role.Hoist,
+		Mentionable: role.Mentionable,
+		Permissions: role.Permissions,
+	}
+}
+
+func (m *Module) registerRoleListeners() {
+	for _, guild := range m.getStateGuilds() {
+		m.snapshotGuildRoles(guild)
+	}
+
+	m.discord.AddHandler(func(_ *discordgo.Session, guildCreate *discordgo.GuildCreate) {
+		m.snapshotGuildRoles(guildCreate.Guild)
+	})
+	m.discord.AddHandler(m.handleRoleCreate)
+	m.discord.AddHandler(m.handleRoleUpdate)
+	m.discord.AddHandler(m.handleRoleDelete)
+}
+
+func (m *Module) snapshotGuildRoles(guild *discordgo.Guild) {
+	for _, role := range guild.Roles {
+		m.roleSnapshots.Set(role.ID, newRoleSnapshot(guild.ID, role))
+	}
+}
+
+func (m *Module) handleRoleCreate(_ *discordgo.Session, roleCreate *discordgo.GuildRoleCreate) {
+	snapshot := newRoleSnapshot(roleCreate.GuildID, roleCreate.Role)
+	m.roleSnapshots.Set(roleCreate.Role.ID, snapshot)
+
+	if !m.isLoggingEnabled(roleCreate.GuildID, RoleCreate) {
+		return
+	}
+
+	data := roleLogData(roleCreate.Role.ID, snapshot)
+	data["permissions"] = formatPermissionList(snapshot.Permissions)
+	m.findAuditLogAttribution(roleCreate.GuildID, discordgo.AuditLogActionRoleCreate, roleCreate.Role.ID).addToLogData(data)
+
+	m.sendLogToDiscord(roleCreate.GuildID, RoleCreate, data)
+}
+
+func (m *Module) handleRoleUpdate(_ *discordgo.Session, roleUpdate *discordgo.GuildRoleUpdate) {
+	snapshot := newRoleSnapshot(roleUpdate.GuildID, roleUpdate.Role)
+	oldSnapshot, found := m.roleSnapshots.Get(roleUpdate.Role.ID)
+	m.roleSnapshots.Set(roleUpdate.Role.ID, snapshot)
+
+	if !found || !m.isLoggingEnabled(roleUpdate.GuildID, RoleUpdate) {
+		return
+	}
+
+	changes := findRoleChanges(oldSnapshot, snapshot)
+	if len(changes) == 0 {
+		// position changes
+		return
+	}
+
+	data := roleLogData(roleUpdate.Role.ID, snapshot)
+	data["old_role_name"] = oldSnapshot.Name
+	data["changes"] = strings.Join(changes, "\n")
+	data["permission_changes"] = formatOptionalString(formatPermissionDifferences(oldSnapshot.Permissions, snapshot.Permissions))
+	m.findAuditLogAttribution(roleUpdate.GuildID, discordgo.AuditLogActionRoleUpdate, roleUpdate.Role.ID).addToLogData(data)
+
+	m.sendLogToDiscord(roleUpdate.GuildID, RoleUpdate, data)
+}
+
+func (m *Module) handleRoleDelete(_ *discordgo.Session, roleDelete *discordgo.GuildRoleDelete) {
+	snapshot, found := m.roleSnapshots.Get(roleDelete.RoleID)
+	if !found {
+		snapshot = roleSnapshot{GuildID: roleDelete.GuildID, Name: "Unknown Role"}
+	}
+	m.roleSnapshots.Delete(roleDelete.RoleID)
+
+	if !m.isLoggingEnabled(roleDelete.GuildID, RoleDelete) {
+		return
+	}
+
+	data := roleLogData(roleDelete.RoleID, snapshot)
+	data["permissions"] = formatPermissionList(snapshot.Permissions)
+	m.findAuditLogAttribution(roleDelete.GuildID, discordgo.AuditLogActionRoleDelete, roleDelete.RoleID).addToLogData(data)
+
+	m.sendLogToDiscord(roleDelete.GuildID, RoleDelete, data)
+}
+
+func roleLogData(roleID string, snapshot roleSnapshot) map[string]string {
+	return map[string]string{
+		"role_id":    roleID,
+		"role_name":  snapshot.Name,
+		"role_color": formatRoleColor(snapshot.Color),
+	}
+}
+
+func findRoleChanges(oldSnapshot roleSnapshot, newSnapshot roleSnapshot) []string {
+	var changes []string
+
+	if oldSnapshot.Name != newSnapshot.Name {
+		changes = append(changes, "Name: `"+oldSnapshot.Name+"` → `"+newSnapshot.Name+"`")
+	}
+	if oldSnapshot.Color != newSnapshot.Color {
+		changes = append(changes, "Colour: "+formatRoleColor(oldSnapshot.Color)+" → "+formatRoleColor(newSnapshot.Color))
+	}
+	if oldSnapshot.Hoist != newSnapshot.Hoist {
+		changes = append(changes, "Displayed separately: "+strconv.FormatBool(oldSnapshot.Hoist)+" → "+strconv.FormatBool(newSnapshot.Hoist))
+	}
+	if oldSnapshot.Mentionable != newSnapshot.Mentionable {
+		changes = append(changes, "Mentionable: "+strconv.FormatBool(oldSnapshot.Mentionable)+" → "+strconv.FormatBool(newSnapshot.Mentionable))
+	}
+	if oldSnapshot.Permissions != newSnapshot.Permissions {
+		changes = append(changes, "Permissions: "+formatPermissionDifferences(oldSnapshot.Permissions, newSnapshot.Permissions))
+	}
+
+	return changes
+}
+
+func formatRoleColor(color int) string {
+	if color == 0 {
+		return "Default"
+	}
+	return fmt.Sprintf("#%06X", color)
+}
+
+func formatPermissionList(permissions int64) string {
+	return formatOptionalString(strings.Join(mapPermissionsToNames(permissions), ", "))
+}
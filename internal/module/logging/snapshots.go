@@ -0,0 +1,32 @@
+package logging
+
+import "sync"
+
+// snapshotStore keeps copies of Discord objects, since the state cache is already updated when update handlers run.
+type snapshotStore[V any] struct {
+	mutex  sync.RWMutex
+	values map[string]V
+}
+
+func newSnapshotStore[V any]() *snapshotStore[V] {
+	return &snapshotStore[V]{values: make(map[string]V)}
+}
+
+func (s *snapshotStore[V]) Get(id string) (V, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	v, ok := s.values[id]
+	return v, ok
+}
+
+func (s *snapshotStore[V]) Set(id string, value V) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.values[id] = value
+}
+
+func (s *snapshotStore[V]) Delete(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.values, id)
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/util"
+	"html/template"
+	"strings"
+	"time"
+)
+
+type transcriptEntry struct {
+	MessageID string
+	Timestamp time.Time
+	Cached    bool
+	Message   CachedMessage
+}
+
+func newTranscriptEntry(messageID string, cachedMsg *CachedMessage) transcriptEntry {
+	timestamp, _ := discordgo.SnowflakeTimestamp(messageID)
+	entry := transcriptEntry{
+		MessageID: messageID,
+		Timestamp: timestamp,
+	}
+	if cachedMsg != nil {
+		entry.Cached = true
+		entry.Message = *cachedMsg
+	}
+	return entry
+}
+
+func (e transcriptEntry) ReplyTarget() string {
+	return formatReplyTarget(e.Message)
+}
+
+func formatReplyTarget(cachedMsg CachedMessage) string {
+	if len(cachedMsg.ReplyToAuthorFullName) > 0 {
+		return cachedMsg.ReplyToAuthorFullName + " (message " + cachedMsg.ReplyToMessageID + ")"
+	}
+	return "message " + cachedMsg.ReplyToMessageID
+}
+
+func buildTextTranscript(channelName string, entries []transcriptEntry) string {
+	var sb strings.Builder
+	sb.WriteString("Bulk delete transcript for #" + channelName + "\n")
+	sb.WriteString("Generated " + time.Now().UTC().Format(time.RFC3339) + " by gowlbot " + util.GetVersionString() + "\n\n")
+
+	for _, entry := range entries {
+		sb.WriteString("[" + entry.Timestamp.UTC().Format(time.RFC3339) + "] ")
+		if !entry.Cached {
+			sb.WriteString("(message " + entry.MessageID + " not found in cache)\n")
+			continue
+		}
+		sb.WriteString(entry.Message.AuthorFullName + " (" + entry.Message.AuthorID + "): " + entry.Message.Content + "\n")
+		if len(entry.Message.ReplyToMessageID) > 0 {
+			sb.WriteString("    Reply to: " + formatReplyTarget(entry.Message) + "\n")
+		}
+		for _, sticker := range entry.Message.Stickers {
+			sb.WriteString("    Sticker: " + sticker + "\n")
+		}
+		for _, embed := range entry.Message.Embeds {
+			sb.WriteString("    Embed: " + strings.ReplaceAll(strings.TrimSpace(embed.Title+"\n"+embed.Description), "\n", "\n    ") + "\n")
+		}
+		for _, attachment := range entry.Message.Attachments {
+			sb.WriteString("    Attachment: " + attachment.Filename + " (" + formatFileSize(attachment.Size) + ") " + attachment.URL + "\n")
+		}
+	}
+	return sb.String()
+}
+
+var htmlTranscriptTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Bulk delete transcript for #{{.ChannelName}}</title>
+<style>
+body { background: #313338; color: #dbdee1; font-family: "gg sans", "Helvetica Neue", Helvetica, Arial, sans-serif; margin: 24px; }
+h1 { font-size: 18px; color: #f2f3f5; }
+.meta { color: #949ba4; font-size: 12px; margin-bottom: 24px; }
+.message { padding: 6px 0; border-bottom: 1px solid #3f4147; }
+.author { font-weight: 600; color: #f2f3f5; }
+.id, .time { color: #949ba4; font-size: 12px; margin-left: 6px; }
+.content { white-space: pre-wrap; word-wrap: break-word; margin-top: 2px; }
+.missing { color: #949ba4; font-style: italic; }
+.reply { color: #949ba4; font-size: 12px; }
+.embed { border-left: 4px solid #1e1f22; background: #2b2d31; padding: 6px 10px; margin-top: 4px; white-space: pre-wrap; }
+.embed-title { font-weight: 600; }
+.sticker { font-size: 13px; }
+.attachment { font-size: 13px; }
+.attachment a { color: #00a8fc; }
+</style>
+</head>
+<body>
+<h1>Bulk delete transcript for #{{.ChannelName}}</h1>
+<div class="meta">{{len .Entries}} messages &middot; generated {{.GeneratedAt}} by gowlbot {{.Version}}</div>
+{{range .Entries}}<div class="message">
+{{if .Cached}}<span class="author">{{.Message.AuthorFullName}}</span><span class="id">{{.Message.AuthorID}}</span><span class="time">{{.Timestamp.UTC.Format "2006-01-02 15:04:05"}} UTC</span>
+{{if .Message.ReplyToMessageID}}<div class="reply">↪ Reply to {{.ReplyTarget}}</div>
+{{end}}<div class="content">{{.Message.Content}}</div>
+{{range .Message.Embeds}}<div class="embed">{{if .Title}}<div class="embed-title">{{.Title}}</div>{{end}}{{.Description}}</div>
+{{end}}{{range .Message.Stickers}}<div class="sticker">🏷 Sticker: {{.}}</div>
+{{end}}{{range .Message.Attachments}}<div class="attachment">📎 <a href="{{.URL}}">{{.Filename}}</a></div>
+{{end}}{{else}}<span class="time">{{.Timestamp.UTC.Format "2006-01-02 15:04:05"}} UTC</span>
+<div class="content missing">Message {{.MessageID}} was not found in the cache.</div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
+
+func buildHTMLTranscript(channelName string, entries []transcriptEntry) (string, error) {
+	var buf bytes.Buffer
+	err := htmlTranscriptTemplate.Execute(&buf, struct {
+		ChannelName string
+		GeneratedAt string
+		Version     string
+		Entries     []transcriptEntry
+	}{
+		ChannelName: channelName,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Version:     util.GetVersionString(),
+		Entries:     entries,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
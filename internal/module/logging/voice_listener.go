@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"strings"
+	"time"
+)
+
+func (m *Module) registerVoiceListeners() {
+	m.discord.AddHandler(m.handleVoiceStateUpdate)
+}
+
+func (m *Module) handleVoiceStateUpdate(_ *discordgo.Session, voiceUpdate *discordgo.VoiceStateUpdate) {
+	if len(voiceUpdate.GuildID) == 0 {
+		return
+	}
+	before := voiceUpdate.BeforeUpdate
+	sessionKey := voiceUpdate.GuildID + ":" + voiceUpdate.UserID
+
+	var oldChannelID string
+	if before != nil {
+		oldChannelID = before.ChannelID
+	}
+
+	data := map[string]string{
+		"member_id":        voiceUpdate.UserID,
+		"member_full_name": m.getMemberFullName(voiceUpdate.GuildID, voiceUpdate.UserID, voiceUpdate.Member),
+		"channel_id":       voiceUpdate.ChannelID,
+		"old_channel_id":   oldChannelID,
+	}
+
+	switch {
+	case len(oldChannelID) == 0 && len(voiceUpdate.ChannelID) > 0:
+		m.voiceSessions.Set(sessionKey, time.Now())
+		m.sendLogToDiscord(voiceUpdate.GuildID, VoiceJoin, data)
+
+	case len(oldChannelID) > 0 && len(voiceUpdate.ChannelID) == 0:
+		data["channel_id"] = oldChannelID
+		data["session_duration"] = "Unknown"
+		if joinedAt, ok := m.voiceSessions.Get(sessionKey); ok {
+			data["session_duration"] = formatDuration(time.Since(joinedAt))
+		}
+		m.voiceSessions.Delete(sessionKey)
+		m.sendLogToDiscord(voiceUpdate.GuildID, VoiceLeave, data)
+
+	case len(oldChannelID) > 0 && oldChannelID != voiceUpdate.ChannelID:
+		m.sendLogToDiscord(voiceUpdate.GuildID, VoiceMove, data)
+
+	case before != nil:
+		changes := findVoiceStateChanges(before, voiceUpdate.VoiceState)
+		if len(changes) == 0 || !m.isLoggingEnabled(voiceUpdate.GuildID, VoiceStateChange) {
+			return
+		}
+		data["changes"] = strings.Join(changes, ", ")
+
+		// only server mutes and deafens are done by moderators, everything else is done by the member
+		if before.Mute != voiceUpdate.Mute || before.Deaf != voiceUpdate.Deaf {
+			m.findAuditLogAttribution(voiceUpdate.GuildID, discordgo.AuditLogActionMemberUpdate, voiceUpdate.UserID).addToLogData(data)
+		}
+		m.sendLogToDiscord(voiceUpdate.GuildID, VoiceStateChange, data)
+	}
+}
+
+func findVoiceStateChanges(before *discordgo.VoiceState, after *discordgo.VoiceState) []string {
+	var changes []string
+
+	describe := func(oldValue bool, newValue bool, enabled string, disabled string) {
+		if oldValue == newValue {
+			return
+		}
+		if newValue {
+			changes = append(changes, enabled)
+		} else {
+			changes = append(changes, disabled)
+		}
+	}
+	describe(before.Mute, after.Mute, "Server muted", "Server unmuted")
+	describe(before.Deaf, after.Deaf, "Server deafened", "Server undeafened")
+	describe(before.SelfStream, after.SelfStream, "Started streaming", "Stopped streaming")
+	describe(before.SelfVideo, after.SelfVideo, "Turned on camera", "Turned off camera")
+
+	return changes
+}
+
+func (m *Module) getMemberFullName(guildID string, userID string, member *discordgo.Member) string {
+	if member != nil && member.User != nil {
+		return member.User.String()
+	}
+	if stateMember, err := m.discord.State.Member(guildID, userID); err == nil && stateMember.User != nil {
+		return stateMember.User.String()
+	}
+	return "Unknown"
+}
+
+func formatDuration(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	hours := int(duration.Hours())
+	minutes := int(duration.Minutes()) % 60
+	seconds := int(duration.Seconds()) % 60
+
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm %ds", hours, minutes, seconds)
+	}
+	if minutes > 0 {
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
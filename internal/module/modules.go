@@ -62,7 +62,8 @@ func StartModules(smi StartModuleInjection) error {
 			for _, newCmd := range commands {
 				commandHandled := false
 				for _, oldCmd := range oldCmds {
-					if oldCmd.Name == newCmd.CmdName {
+					// context menu commands can share their name with a slash command
+					if oldCmd.Name == newCmd.CmdName && oldCmd.Type == newCmd.Type() {
 						if oldCmd.Version != newCmd.Version {
 							_, err := smi.Discord.ApplicationCommandEdit(smi.Config.Discord.ApplicationID, guildID, oldCmd.ID, &newCmd.Command)
 							if err != nil {
@@ -3,9 +3,11 @@ package notifications
 import (
 	"context"
 	"github.com/bwmarrin/discordgo"
+	"github.com/yannismate/gowlbot/internal/templating"
 	"github.com/yannismate/gowlbot/internal/util"
 	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -82,17 +84,21 @@ func (m *Module) sendTwitchNotification(notification GuildNotification, userName
 
 	thumbnailURLReplacer := strings.NewReplacer("{width}", "1280", "{height}", "720")
 
-	replaceList := []string{
-		"{twitch_name}", userName,
-		"{twitch_url}", twitchURL,
-		"{twitch_game_name}", gameName,
-		"{twitch_title}", title,
-		"{twitch_thumbnail_url}", thumbnailURLReplacer.Replace(thumbnailURL),
+	tmpl, err := templating.Parse(notification.Format)
+	if err != nil {
+		m.logger.Warn("Invalid twitch notification format", zap.String("guild", notification.GuildID), zap.Int64("notification", notification.ID), zap.Error(err))
+		return
 	}
-	replacer := strings.NewReplacer(replaceList...)
 
 	msg := discordgo.MessageSend{
-		Content: replacer.Replace(notification.Format),
+		Content: tmpl.Execute(map[string]string{
+			"twitch_name":          userName,
+			"twitch_url":           twitchURL,
+			"twitch_game_name":     gameName,
+			"twitch_title":         title,
+			"twitch_thumbnail_url": thumbnailURLReplacer.Replace(thumbnailURL),
+			"started_at":           strconv.FormatInt(startedAt.Unix(), 10),
+		}),
 		Embed: &discordgo.MessageEmbed{
 			Title: userName + " - Twitch",
 			URL:   twitchURL,
@@ -116,7 +122,7 @@ func (m *Module) sendTwitchNotification(notification GuildNotification, userName
 			},
 		},
 	}
-	_, err := m.discord.ChannelMessageSendComplex(notification.ChannelID, &msg)
+	_, err = m.discord.ChannelMessageSendComplex(notification.ChannelID, &msg)
 	if err != nil {
 		m.logger.Warn("Error sending twitch notification message", zap.String("guild", notification.GuildID), zap.String("channel", notification.ChannelID), zap.Error(err))
 	}
@@ -0,0 +1,185 @@
+package templating
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxTruncateLength = 4000
+
+type filterFunc func(value string, argument string) string
+
+type filterDefinition struct {
+	// argument is "required", "optional" or "none"
+	argument string
+	validate func(argument string) error
+	apply    filterFunc
+}
+
+type appliedFilter struct {
+	definition filterDefinition
+	argument   string
+}
+
+func (f appliedFilter) apply(value string) string {
+	return f.definition.apply(value, f.argument)
+}
+
+var (
+	markdownReplacer = strings.NewReplacer(
+		"\\", "\\\\",
+		"*", "\\*",
+		"_", "\\_",
+		"~", "\\~",
+		"`", "\\`",
+		"|", "\\|",
+		">", "\\>",
+		"#", "\\#",
+		"[", "\\[",
+		"]", "\\]",
+	)
+
+	filters = map[string]filterDefinition{
+		// default:"text" replaces empty values
+		"default": {
+			argument: "required",
+			apply: func(value string, argument string) string {
+				if len(value) == 0 {
+					return argument
+				}
+				return value
+			},
+		},
+		// truncate:100 limits the value to 100 characters
+		"truncate": {
+			argument: "required",
+			validate: func(argument string) error {
+				length, err := strconv.Atoi(argument)
+				if err != nil || length < 1 || length > maxTruncateLength {
+					return errors.New("truncate expects a length between 1 and " + strconv.Itoa(maxTruncateLength))
+				}
+				return nil
+			},
+			apply: func(value string, argument string) string {
+				length, _ := strconv.Atoi(argument)
+				if utf8.RuneCountInString(value) <= length {
+					return value
+				}
+				runes := []rune(value)
+				return string(runes[:length-1]) + "…"
+			},
+		},
+		"upper": {
+			argument: "none",
+			apply: func(value string, _ string) string {
+				return strings.ToUpper(value)
+			},
+		},
+		"lower": {
+			argument: "none",
+			apply: func(value string, _ string) string {
+				return strings.ToLower(value)
+			},
+		},
+		// escape prevents markdown formatting of the value
+		"escape": {
+			argument: "none",
+			apply: func(value string, _ string) string {
+				return markdownReplacer.Replace(value)
+			},
+		},
+		// code wraps the value in a code block, code:"diff" adds a language
+		"code": {
+			argument: "optional",
+			apply: func(value string, argument string) string {
+				return "```" + argument + "\n" + strings.ReplaceAll(value, "`", "`\u200B") + "```"
+			},
+		},
+		"inline_code": {
+			argument: "none",
+			apply: func(value string, _ string) string {
+				if len(value) == 0 {
+					return value
+				}
+				return "`" + strings.ReplaceAll(value, "`", "'") + "`"
+			},
+		},
+		// timestamp formats unix seconds as Discord timestamp, timestamp:R renders a relative time
+		"timestamp": {
+			argument: "optional",
+			validate: func(argument string) error {
+				if len(argument) > 0 && (len(argument) != 1 || !strings.Contains("tTdDfFR", argument)) {
+					return errors.New("timestamp expects one of the styles t, T, d, D, f, F or R")
+				}
+				return nil
+			},
+			apply: func(value string, argument string) string {
+				if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+					return value
+				}
+				if len(argument) == 0 {
+					return "<t:" + value + ">"
+				}
+				return "<t:" + value + ":" + argument + ">"
+			},
+		},
+		"user": {
+			argument: "none",
+			apply:    mentionFilter("<@", ">"),
+		},
+		"channel": {
+			argument: "none",
+			apply:    mentionFilter("<#", ">"),
+		},
+		"role": {
+			argument: "none",
+			apply:    mentionFilter("<@&", ">"),
+		},
+	}
+)
+
+// mentionFilter only mentions snowflake ids, so values like "Unknown" are not turned into broken mentions.
+func mentionFilter(prefix string, suffix string) filterFunc {
+	return func(value string, _ string) string {
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return value
+		}
+		return prefix + value + suffix
+	}
+}
+
+func parseFilter(expression string) (appliedFilter, error) {
+	name, argument, hasArgument := strings.Cut(expression, ":")
+	name = strings.TrimSpace(name)
+
+	definition, ok := filters[name]
+	if !ok {
+		return appliedFilter{}, errors.New("unknown filter \"" + name + "\"")
+	}
+
+	if hasArgument {
+		if definition.argument == "none" {
+			return appliedFilter{}, errors.New("filter \"" + name + "\" does not take an argument")
+		}
+		argument = strings.TrimSpace(argument)
+		if strings.HasPrefix(argument, "\"") {
+			unquoted, err := strconv.Unquote(argument)
+			if err != nil {
+				return appliedFilter{}, errors.New("invalid string argument for filter \"" + name + "\"")
+			}
+			argument = unquoted
+		}
+	} else if definition.argument == "required" {
+		return appliedFilter{}, errors.New("filter \"" + name + "\" requires an argument")
+	}
+
+	if definition.validate != nil {
+		if err := definition.validate(argument); err != nil {
+			return appliedFilter{}, err
+		}
+	}
+
+	return appliedFilter{definition: definition, argument: argument}, nil
+}
@@ -0,0 +1,360 @@
+// Package templating renders the user configurable formats of log messages and notifications.
+//
+// Formats are plain text with tags in curly braces:
+//
+//	{name}                      inserts a variable, unknown or missing variables are empty
+//	{name|default:"Unknown"}    pipes the variable through filters, see filters.go
+//	{if name}...{else}...{end}  renders a section only if the variable is not empty, {if !name} negates the condition
+//	{{ and }}                   insert literal braces
+//
+// The language has no loops, function calls or data access beyond the variables passed to Execute, so formats
+// supplied by server admins can be rendered safely.
+package templating
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const maxNestingDepth = 8
+
+var ErrNestingTooDeep = errors.New("conditions are nested too deeply")
+
+// Error describes a syntax error in a format, Position is the byte offset of the offending tag.
+type Error struct {
+	Position int
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (at position %d)", e.Message, e.Position)
+}
+
+// UnknownVariablesError is returned by Validate if a format references variables that are not available.
+type UnknownVariablesError struct {
+	Names []string
+}
+
+func (e *UnknownVariablesError) Error() string {
+	quoted := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		quoted[i] = "{" + name + "}"
+	}
+	return "unknown variables: " + strings.Join(quoted, ", ")
+}
+
+type nodeKind int
+
+const (
+	nodeText nodeKind = iota
+	nodeVariable
+	nodeCondition
+)
+
+type node struct {
+	kind      nodeKind
+	text      string
+	variable  string
+	filters   []appliedFilter
+	negate    bool
+	then      []node
+	otherwise []node
+}
+
+type Template struct {
+	nodes     []node
+	variables []string
+}
+
+// Parse compiles a format, the returned error is an *Error for syntax errors.
+func Parse(source string) (*Template, error) {
+	p := parser{source: source}
+	nodes, terminator, err := p.parseNodes(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(terminator) > 0 {
+		return nil, &Error{Position: p.tagStart, Message: "{" + terminator + "} without {if}"}
+	}
+
+	variableSet := make(map[string]bool)
+	collectVariables(nodes, variableSet)
+	variables := make([]string, 0, len(variableSet))
+	for variable := range variableSet {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	return &Template{nodes: nodes, variables: variables}, nil
+}
+
+// Variables returns the sorted names of all variables referenced by the template.
+func (t *Template) Variables() []string {
+	return t.variables
+}
+
+func (t *Template) UsesVariable(name string) bool {
+	idx := sort.SearchStrings(t.variables, name)
+	return idx < len(t.variables) && t.variables[idx] == name
+}
+
+// Validate returns an *UnknownVariablesError if the template references variables that are not in allowed.
+func (t *Template) Validate(allowed []string) error {
+	allowedSet := make(map[string]bool)
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+
+	var unknown []string
+	for _, variable := range t.variables {
+		if !allowedSet[variable] {
+			unknown = append(unknown, variable)
+		}
+	}
+	if len(unknown) > 0 {
+		return &UnknownVariablesError{Names: unknown}
+	}
+	return nil
+}
+
+// Escaper is applied to a variable after its filters, so filters and conditions work on the raw value.
+type Escaper func(value string) string
+
+func (t *Template) Execute(data map[string]string) string {
+	return t.ExecuteEscaped(data, nil)
+}
+
+// ExecuteEscaped renders the template like Execute and passes the variables in escapers through their escaper last.
+func (t *Template) ExecuteEscaped(data map[string]string, escapers map[string]Escaper) string {
+	var builder strings.Builder
+	executeNodes(&builder, t.nodes, data, escapers)
+	return builder.String()
+}
+
+func executeNodes(builder *strings.Builder, nodes []node, data map[string]string, escapers map[string]Escaper) {
+	for _, n := range nodes {
+		switch n.kind {
+		case nodeText:
+			builder.WriteString(n.text)
+		case nodeVariable:
+			value := data[n.variable]
+			for _, filter := range n.filters {
+				value = filter.apply(value)
+			}
+			if escaper, ok := escapers[n.variable]; ok {
+				value = escaper(value)
+			}
+			builder.WriteString(value)
+		case nodeCondition:
+			if (len(data[n.variable]) > 0) != n.negate {
+				executeNodes(builder, n.then, data, escapers)
+			} else {
+				executeNodes(builder, n.otherwise, data, escapers)
+			}
+		}
+	}
+}
+
+func collectVariables(nodes []node, variables map[string]bool) {
+	for _, n := range nodes {
+		if n.kind == nodeVariable || n.kind == nodeCondition {
+			variables[n.variable] = true
+		}
+		collectVariables(n.then, variables)
+		collectVariables(n.otherwise, variables)
+	}
+}
+
+type parser struct {
+	source   string
+	pos      int
+	tagStart int
+}
+
+// parseNodes parses until the end of the source or an {else} or {end} tag, which is returned as terminator.
+func (p *parser) parseNodes(depth int) ([]node, string, error) {
+	var nodes []node
+	var text strings.Builder
+
+	flushText := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, node{kind: nodeText, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		if c == '}' {
+			// a single closing brace is kept as is, a double one is an escaped brace
+			if strings.HasPrefix(p.source[p.pos:], "}}") {
+				p.pos++
+			}
+			text.WriteByte('}')
+			p.pos++
+			continue
+		}
+		if c != '{' {
+			text.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if strings.HasPrefix(p.source[p.pos:], "{{") {
+			text.WriteByte('{')
+			p.pos += 2
+			continue
+		}
+
+		p.tagStart = p.pos
+		tag, err := p.readTag()
+		if err != nil {
+			return nil, "", err
+		}
+		flushText()
+
+		switch {
+		case tag == "else" || tag == "end":
+			return nodes, tag, nil
+		case strings.HasPrefix(tag, "if ") || tag == "if":
+			if depth >= maxNestingDepth {
+				return nil, "", &Error{Position: p.tagStart, Message: ErrNestingTooDeep.Error()}
+			}
+			conditionNode, err := p.parseCondition(tag, depth)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, conditionNode)
+		default:
+			variableNode, err := p.parseVariable(tag)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, variableNode)
+		}
+	}
+
+	flushText()
+	return nodes, "", nil
+}
+
+// readTag returns the trimmed content between the braces of the tag at the current position.
+func (p *parser) readTag() (string, error) {
+	inQuotes := false
+	for i := p.pos + 1; i < len(p.source); i++ {
+		switch p.source[i] {
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case '}':
+			if !inQuotes {
+				tag := strings.TrimSpace(p.source[p.pos+1 : i])
+				p.pos = i + 1
+				return tag, nil
+			}
+		}
+	}
+	return "", &Error{Position: p.pos, Message: "unclosed tag"}
+}
+
+func (p *parser) parseCondition(tag string, depth int) (node, error) {
+	tagStart := p.tagStart
+	condition := strings.TrimSpace(strings.TrimPrefix(tag, "if"))
+
+	conditionNode := node{kind: nodeCondition}
+	if strings.HasPrefix(condition, "!") {
+		conditionNode.negate = true
+		condition = strings.TrimSpace(condition[1:])
+	}
+	if !isIdentifier(condition) {
+		return node{}, &Error{Position: tagStart, Message: "invalid condition \"" + condition + "\", expected a variable name"}
+	}
+	conditionNode.variable = condition
+
+	then, terminator, err := p.parseNodes(depth + 1)
+	if err != nil {
+		return node{}, err
+	}
+	conditionNode.then = then
+
+	if terminator == "else" {
+		otherwise, elseTerminator, err := p.parseNodes(depth + 1)
+		if err != nil {
+			return node{}, err
+		}
+		if elseTerminator == "else" {
+			return node{}, &Error{Position: p.tagStart, Message: "duplicate {else}"}
+		}
+		terminator = elseTerminator
+		conditionNode.otherwise = otherwise
+	}
+	if terminator != "end" {
+		return node{}, &Error{Position: tagStart, Message: "{if} without {end}"}
+	}
+
+	return conditionNode, nil
+}
+
+func (p *parser) parseVariable(tag string) (node, error) {
+	parts, err := splitOutsideQuotes(tag, '|')
+	if err != nil {
+		return node{}, &Error{Position: p.tagStart, Message: err.Error()}
+	}
+
+	name := strings.TrimSpace(parts[0])
+	if !isIdentifier(name) {
+		return node{}, &Error{Position: p.tagStart, Message: "invalid variable name \"" + name + "\""}
+	}
+
+	variableNode := node{kind: nodeVariable, variable: name}
+	for _, part := range parts[1:] {
+		filter, err := parseFilter(strings.TrimSpace(part))
+		if err != nil {
+			return node{}, &Error{Position: p.tagStart, Message: err.Error()}
+		}
+		variableNode.filters = append(variableNode.filters, filter)
+	}
+	return variableNode, nil
+}
+
+func splitOutsideQuotes(s string, separator byte) ([]string, error) {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inQuotes {
+				i++
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case separator:
+			if !inQuotes {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated string")
+	}
+	return append(parts, s[start:]), nil
+}
+
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i, c := range s {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		if !isLetter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
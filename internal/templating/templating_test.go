@@ -0,0 +1,187 @@
+package templating
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		message  string
+		position int
+	}{
+		{name: "unclosed tag", source: "hello {name", message: "unclosed tag", position: 6},
+		{name: "invalid variable name", source: "{1name}", message: "invalid variable name \"1name\""},
+		{name: "empty tag", source: "a {} b", message: "invalid variable name \"\"", position: 2},
+		{name: "if without end", source: "{if name}x", message: "{if} without {end}"},
+		{name: "end without if", source: "x{end}", message: "{end} without {if}", position: 1},
+		{name: "else without if", source: "{else}", message: "{else} without {if}"},
+		{name: "duplicate else", source: "{if a}x{else}y{else}z{end}", message: "duplicate {else}", position: 14},
+		{name: "invalid condition", source: "{if a b}x{end}", message: "invalid condition \"a b\", expected a variable name"},
+		{name: "unknown filter", source: "{name|shout}", message: "unknown filter \"shout\""},
+		{name: "unterminated string", source: "{name|default:\"x}", message: "unclosed tag"},
+		{name: "nesting too deep", source: strings.Repeat("{if a}", maxNestingDepth+1), message: ErrNestingTooDeep.Error(), position: 6 * maxNestingDepth},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.source)
+			var syntaxErr *Error
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntaxErr.Message != test.message || syntaxErr.Position != test.position {
+				t.Errorf("got %q at %d, want %q at %d", syntaxErr.Message, syntaxErr.Position, test.message, test.position)
+			}
+		})
+	}
+}
+
+func TestFilterArgumentValidation(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{source: "{name|default}", message: "filter \"default\" requires an argument"},
+		{source: "{name|truncate}", message: "filter \"truncate\" requires an argument"},
+		{source: "{name|truncate:0}", message: "truncate expects a length between 1 and 4000"},
+		{source: "{name|truncate:4001}", message: "truncate expects a length between 1 and 4000"},
+		{source: "{name|truncate:abc}", message: "truncate expects a length between 1 and 4000"},
+		{source: "{name|upper:1}", message: "filter \"upper\" does not take an argument"},
+		{source: "{name|timestamp:x}", message: "timestamp expects one of the styles t, T, d, D, f, F or R"},
+		{source: "{name|default:\"a\\q\"}", message: "invalid string argument for filter \"default\""},
+		{source: "{name|truncate:10}"},
+		{source: "{name|timestamp:R}"},
+		{source: "{name|timestamp}"},
+		{source: "{name|code}"},
+		{source: "{name|code:\"ansi\"}"},
+		{source: "{name|default:\"a | b\"}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			_, err := Parse(test.source)
+			if len(test.message) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var syntaxErr *Error
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("got %v, want a syntax error", err)
+			}
+			if syntaxErr.Message != test.message {
+				t.Errorf("got %q, want %q", syntaxErr.Message, test.message)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownVariables(t *testing.T) {
+	tests := []struct {
+		source  string
+		allowed []string
+		unknown []string
+	}{
+		{source: "{a} {b}", allowed: []string{"a", "b"}},
+		{source: "{a} {c|upper} {if d}{e}{end}", allowed: []string{"a"}, unknown: []string{"c", "d", "e"}},
+		{source: "{if !b}x{else}{a}{end}", allowed: []string{"a"}, unknown: []string{"b"}},
+		{source: "{{not_a_variable}}", allowed: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			tmpl, err := Parse(test.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			err = tmpl.Validate(test.allowed)
+			if len(test.unknown) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var unknownErr *UnknownVariablesError
+			if !errors.As(err, &unknownErr) {
+				t.Fatalf("got %v, want an unknown variables error", err)
+			}
+			if !reflect.DeepEqual(unknownErr.Names, test.unknown) {
+				t.Errorf("got %v, want %v", unknownErr.Names, test.unknown)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	data := map[string]string{
+		"name":  "gowl*bot",
+		"id":    "123",
+		"empty": "",
+		"time":  "1672531200",
+	}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{source: "Hello {name}!", want: "Hello gowl*bot!"},
+		{source: "{missing}|{empty}", want: "|"},
+		{source: "{{name}} }", want: "{name} }"},
+		{source: "{empty|default:\"Unknown\"}", want: "Unknown"},
+		{source: "{name|upper|escape}", want: "GOWL\\*BOT"},
+		{source: "{name|truncate:4}", want: "gow…"},
+		{source: "{id|user} {name|user} {id|role} {id|channel}", want: "<@123> gowl*bot <@&123> <#123>"},
+		{source: "{time|timestamp:R}", want: "<t:1672531200:R>"},
+		{source: "{if empty}yes{else}no{end}", want: "no"},
+		{source: "{if !empty}yes{end}", want: "yes"},
+		{source: "{if name}{if id}both{end}{end}", want: "both"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			tmpl, err := Parse(test.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := tmpl.Execute(data); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExecuteEscapedAppliesFiltersFirst(t *testing.T) {
+	escapers := map[string]Escaper{
+		"content": func(value string) string { return "```" + value + "```" },
+	}
+	tests := []struct {
+		name    string
+		source  string
+		content string
+		want    string
+	}{
+		{name: "truncate keeps the fence", source: "{content|truncate:5}", content: "hello world", want: "```hell…```"},
+		{name: "default before escaping", source: "{content|default:\"none\"}", content: "", want: "```none```"},
+		{name: "condition on empty raw value", source: "{if content}{content}{else}empty{end}", content: "", want: "empty"},
+		{name: "condition on raw value", source: "{if content}{content}{end}", content: "hi", want: "```hi```"},
+		{name: "other variables unescaped", source: "{other}", content: "hi", want: "x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := Parse(test.source)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got := tmpl.ExecuteEscaped(map[string]string{"content": test.content, "other": "x"}, escapers)
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}